@@ -32,7 +32,8 @@ CREATE TABLE `im_users_detail` (
   `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '用户详细修改时间',
   constraint `gender_check` check ((`gender` in ('男','女',''))),
   constraint `age_check` check ((`age` >=0 and `age` <= 150)),
-  constraint `fk_ud_to_user` FOREIGN KEY (`user_id`) REFERENCES `im_users` (`user_id`) on delete cascade
+  constraint `fk_ud_to_user` FOREIGN KEY (`user_id`) REFERENCES `im_users` (`user_id`) on delete cascade,
+  index i_avatar(avatar)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- 用户职责表
@@ -54,7 +55,7 @@ DROP TABLE IF EXISTS `im_groups`;
 CREATE TABLE `im_groups` (
   `group_id` bigint auto_increment PRIMARY KEY COMMENT '群号',
   `group_name` varchar(32) not null COMMENT '群名称',
-  `group_password` varchar(20) COMMENT '群密码',
+  `group_password` varchar(64) default '' COMMENT '群密码哈希',
   `created_time` timestamp default current_timestamp COMMENT '群创建时间',
   `updated_time` timestamp default current_timestamp COMMENT '群更新时间',
   `deleted` int default 0 COMMENT '逻辑删除',
@@ -68,11 +69,13 @@ CREATE TABLE `im_groups_detail` (
   `group_avatar` varchar(255) default '' COMMENT '群头像',
   `max_size` int not null COMMENT '群容量',
   `current_size` int not null default 0 COMMENT '群当前人数',
+  `join_policy` varchar(16) not null default 'password' COMMENT '入群方式: open password approval invite',
   -- 暂时不考虑 online_size 字段实现，显然，离线属于账号的特定状态，可以作为 user 本身的一个属性，而不是群属性
   -- `online_size` int default 1 COMMENT '在线人数',
   `created_time` timestamp default current_timestamp COMMENT '群创建时间',
   `updated_time` timestamp default current_timestamp COMMENT '群更新时间',
-  constraint `fk_gd_to_group` FOREIGN KEY (`group_id`) REFERENCES `im_groups` (`group_id`) on delete cascade
+  constraint `fk_gd_to_group` FOREIGN KEY (`group_id`) REFERENCES `im_groups` (`group_id`) on delete cascade,
+  index i_group_avatar(group_avatar)
 )ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- 群用户关系表
@@ -85,6 +88,7 @@ CREATE TABLE `im_groups_users` (
   `user_role` int default 3  COMMENT '用户职责',
   `user_role_nickname` varchar(32) default '' COMMENT '用户职责别称',
   `disturb` int default 1 COMMENT '群打扰模式',
+  `mute_until` timestamp null default null COMMENT '禁言截止时间',
   `created_time` timestamp default current_timestamp COMMENT '用户入群时间',
   `updated_time` timestamp default current_timestamp COMMENT '用户退出群时间',
   `deleted` int default 0 COMMENT '逻辑删除',
@@ -104,10 +108,15 @@ CREATE TABLE `im_single_chat` (
   `invitee_nickname` varchar(32) default '' COMMENT '受邀人别称',
   `inviter_disturb` int default 1 COMMENT '邀请人打扰模式',
   `invitee_disturb` int default 1 COMMENT '受邀请人打扰模式',
+  `status` int not null default 0 COMMENT '申请状态: 0 待处理 1 已接受 2 已拒绝 3 已取消 4 已过期',
   `created_time` timestamp default current_timestamp COMMENT '单聊创建时间',
   `updated_time` timestamp default current_timestamp COMMENT '单聊修改时间',
   `deleted` int default 0 COMMENT '逻辑删除',
   PRIMARY KEY (`single_id`, `inviter_id`, `invitee_id`),
+  unique index u_single(single_id),
+  index i_invitee_status(invitee_id,status),
+  index i_inviter_status(inviter_id,status),
+  index i_status_created(status,created_time),
   constraint `fk_sc_to_users_1` FOREIGN KEY (`inviter_id`) REFERENCES `im_users` (`user_id`) on delete cascade,
   constraint `fk_sc_to_users_2` FOREIGN KEY (`invitee_id`) REFERENCES `im_users` (`user_id`) on delete cascade
 )ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -165,28 +174,141 @@ CREATE TABLE `im_message` (
   `message_id` bigint PRIMARY KEY auto_increment COMMENT '消息标识',
   `sender` bigint COMMENT '发送者',
   `receiver` bigint COMMENT '接收者',
+  `dialog_type` int not null default 1 COMMENT '对话类型',
   `type` int not null COMMENT '消息类型',
   `content` text COMMENT '消息内容',
+  `blob_id` varchar(64) not null default '' COMMENT '媒体消息引用的附件',
   `status` int default 1 COMMENT '消息状态',
+  `read_count` int not null default 0 COMMENT '群消息已读人数',
   `send_time` timestamp default current_timestamp COMMENT '发送时间',
   `deleted` int default 0 COMMENT '逻辑删除',
   constraint `fk_message_to_type`FOREIGN KEY (`type`) REFERENCES `im_message_type` (`type_id`),
-  constraint `fk_message_to_status` FOREIGN KEY (`status`) REFERENCES `im_message_status` (`status_id`)
+  constraint `fk_message_to_status` FOREIGN KEY (`status`) REFERENCES `im_message_status` (`status_id`),
+  constraint `fk_message_to_dialog` FOREIGN KEY (`dialog_type`) REFERENCES `im_dialog` (`dialog_id`),
+  index i_receiver_dtype(receiver,dialog_type),
+  index i_blob(blob_id)
 )ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 -- 历史消息
 DROP TABLE IF EXISTS `im_timeline`;
 CREATE TABLE `im_timeline` (
+  `timeline_type` int not null default 1 COMMENT '时间线类型: 1 用户收件箱 2 群时间线',
   `timeline_id` bigint COMMENT '时间线标识',
-  `sequence_id` timestamp default current_timestamp COMMENT '序列标识',
+  `sequence_id` bigint not null COMMENT '序列标识，时间线内严格递增',
   `sender` bigint not null COMMENT '发送者',
   `dialog_type` int not null COMMENT '对话类型',
   `message_id` bigint not null COMMENT '消息标识',
+  `created_time` timestamp default current_timestamp COMMENT '写入时间',
   `deleted` int default 0 COMMENT '逻辑删除',
-  PRIMARY KEY (`timeline_id`, `sequence_id`),
+  PRIMARY KEY (`timeline_type`, `timeline_id`, `sequence_id`),
   constraint `fk_timeline_to_dialog` FOREIGN KEY (`dialog_type`) REFERENCES `im_dialog` (`dialog_id`),
   constraint `fk_timeline_to_message` FOREIGN KEY (`message_id`) REFERENCES `im_message` (`message_id`),
   index i_sender_dtype(sender,dialog_type)
 )ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- 时间线序列号水位，写入消息的事务锁住对应行分配序列号
+DROP TABLE IF EXISTS `im_timeline_sequence`;
+CREATE TABLE `im_timeline_sequence` (
+  `timeline_type` int not null default 1 COMMENT '时间线类型: 1 用户收件箱 2 群时间线',
+  `timeline_id` bigint not null COMMENT '时间线标识',
+  `sequence_id` bigint not null default 0 COMMENT '已分配的最大序列号',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '更新时间',
+  PRIMARY KEY (`timeline_type`, `timeline_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 群成员已读位置
+DROP TABLE IF EXISTS `im_group_read`;
+CREATE TABLE `im_group_read` (
+  `group_id` bigint not null COMMENT '群组标识',
+  `user_id` bigint not null COMMENT '成员标识',
+  `timeline_type` int not null default 1 COMMENT '已读位置所在时间线类型',
+  `timeline_id` bigint not null COMMENT '已读位置所在时间线',
+  `sequence_id` bigint not null default 0 COMMENT '已读到的序列号',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '更新时间',
+  PRIMARY KEY (`group_id`, `user_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 入群申请
+DROP TABLE IF EXISTS `im_group_join_request`;
+CREATE TABLE `im_group_join_request` (
+  `request_id` bigint auto_increment PRIMARY KEY COMMENT '申请标识',
+  `group_id` bigint not null COMMENT '群号',
+  `user_id` bigint not null COMMENT '申请人账号',
+  `user_nickname` varchar(32) default '' COMMENT '申请人群别称',
+  `message` varchar(255) default '' COMMENT '申请附言',
+  `status` int not null default 0 COMMENT '申请状态: 0 待处理 1 已同意 2 已拒绝',
+  `handler_id` bigint not null default 0 COMMENT '处理人账号',
+  `created_time` timestamp default current_timestamp COMMENT '申请时间',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '处理时间',
+  unique key uk_group_user(group_id,user_id),
+  index i_group_status(group_id,status)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 群封禁
+DROP TABLE IF EXISTS `im_group_ban`;
+CREATE TABLE `im_group_ban` (
+  `group_id` bigint not null COMMENT '群号',
+  `user_id` bigint not null COMMENT '被封禁用户账号',
+  `operator_id` bigint not null COMMENT '操作人账号',
+  `reason` varchar(255) default '' COMMENT '封禁原因',
+  `expire_time` timestamp null default null COMMENT '封禁截止时间，为空表示永久',
+  `created_time` timestamp default current_timestamp COMMENT '封禁时间',
+  PRIMARY KEY (`group_id`, `user_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 群公告
+DROP TABLE IF EXISTS `im_group_announcement`;
+CREATE TABLE `im_group_announcement` (
+  `announcement_id` bigint auto_increment PRIMARY KEY COMMENT '公告标识',
+  `group_id` bigint not null COMMENT '群号',
+  `creator_id` bigint not null COMMENT '发布人账号',
+  `title` varchar(64) not null default '' COMMENT '公告标题',
+  `content` varchar(2048) not null default '' COMMENT '公告内容',
+  `pinned` int not null default 0 COMMENT '是否置顶',
+  `need_ack` int not null default 0 COMMENT '是否需要成员确认',
+  `created_time` timestamp default current_timestamp COMMENT '发布时间',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '修改时间',
+  `deleted` int default 0 COMMENT '逻辑删除',
+  index i_group_pinned(group_id,pinned)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 群公告确认
+DROP TABLE IF EXISTS `im_group_announcement_ack`;
+CREATE TABLE `im_group_announcement_ack` (
+  `announcement_id` bigint not null COMMENT '公告标识',
+  `user_id` bigint not null COMMENT '确认成员账号',
+  `created_time` timestamp default current_timestamp COMMENT '确认时间',
+  PRIMARY KEY (`announcement_id`, `user_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 用户屏蔽表
+DROP TABLE IF EXISTS `im_user_block`;
+CREATE TABLE `im_user_block` (
+  `blocker_id` bigint not null COMMENT '屏蔽者账号',
+  `blocked_id` bigint not null COMMENT '被屏蔽者账号',
+  `created_time` timestamp default current_timestamp COMMENT '屏蔽时间',
+  PRIMARY KEY (`blocker_id`, `blocked_id`),
+  index i_blocked(blocked_id)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 标签表
+DROP TABLE IF EXISTS `im_tag`;
+CREATE TABLE `im_tag` (
+  `tag_id` bigint auto_increment PRIMARY KEY COMMENT '标签标识',
+  `user_id` bigint not null COMMENT '标签所属用户账号',
+  `tag_name` varchar(32) not null COMMENT '标签名',
+  `created_time` timestamp default current_timestamp COMMENT '创建时间',
+  unique index u_user_tag(user_id,tag_name)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 标签关联表
+DROP TABLE IF EXISTS `im_tag_item`;
+CREATE TABLE `im_tag_item` (
+  `tag_id` bigint not null COMMENT '标签标识',
+  `dialog_type` int not null COMMENT '会话类型: 1 单聊 2 群聊',
+  `dialog_id` bigint not null COMMENT '单聊标识或群号',
+  `created_time` timestamp default current_timestamp COMMENT '关联时间',
+  PRIMARY KEY (`tag_id`, `dialog_type`, `dialog_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 set FOREIGN_KEY_CHECKS = 1;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- 消息对话类型: im_message 增加对话类型，区分单聊与群聊消息
+-- 历史消息按其时间线记录上的对话类型回填，没有时间线记录的保持默认的单聊
+
+set NAMES 'utf8mb4';
+
+alter table `im_message`
+  add column `dialog_type` int not null default 1 COMMENT '对话类型' after `receiver`,
+  add constraint `fk_message_to_dialog` FOREIGN KEY (`dialog_type`) REFERENCES `im_dialog` (`dialog_id`),
+  add index i_receiver_dtype(receiver,dialog_type);
+
+update `im_message` im
+  join `im_timeline` it on it.message_id = im.message_id
+  set im.dialog_type = it.dialog_type;
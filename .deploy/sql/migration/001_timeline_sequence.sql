@@ -0,0 +1,19 @@
+-- im_timeline.sequence_id 由 timestamp 改为时间线内严格递增的 bigint
+-- 已有数据按秒级时间戳换算，新分配的序列号从时间线当前最大值继续递增
+
+set NAMES 'utf8mb4';
+
+alter table `im_timeline` add column `sequence_num` bigint not null default 0;
+update `im_timeline` set `sequence_num` = unix_timestamp(`sequence_id`);
+alter table `im_timeline`
+  drop primary key,
+  drop column `sequence_id`,
+  change column `sequence_num` `sequence_id` bigint not null COMMENT '序列标识，时间线内严格递增' after `timeline_id`,
+  add column `created_time` timestamp default current_timestamp COMMENT '写入时间' after `message_id`,
+  add primary key (`timeline_id`, `sequence_id`);
+
+CREATE TABLE IF NOT EXISTS `im_timeline_sequence` (
+  `timeline_id` bigint PRIMARY KEY COMMENT '时间线标识',
+  `sequence_id` bigint not null default 0 COMMENT '已分配的最大序列号',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '更新时间'
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,15 @@
+-- 消息已读: im_message 增加群消息已读人数，新增群成员已读位置表
+
+set NAMES 'utf8mb4';
+
+alter table `im_message`
+  add column `read_count` int not null default 0 COMMENT '群消息已读人数' after `status`;
+
+CREATE TABLE IF NOT EXISTS `im_group_read` (
+  `group_id` bigint not null COMMENT '群组标识',
+  `user_id` bigint not null COMMENT '成员标识',
+  `timeline_id` bigint not null COMMENT '已读位置所在时间线',
+  `sequence_id` bigint not null default 0 COMMENT '已读到的序列号',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '更新时间',
+  PRIMARY KEY (`group_id`, `user_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,7 @@
+-- 群密码加密: group_password 改为保存 bcrypt 哈希，长度与 im_users.user_password 一致
+-- 已有的明文群密码无法在 sql 中转换，保留原值，首次验证通过时由服务端替换为哈希
+
+set NAMES 'utf8mb4';
+
+alter table `im_groups`
+  modify column `group_password` varchar(64) default '' COMMENT '群密码哈希';
@@ -0,0 +1,21 @@
+-- 入群方式: im_groups_detail 增加入群方式，新增入群申请表
+-- 已有的群保持原有行为(凭群密码加入，未设置密码时直接加入)
+
+set NAMES 'utf8mb4';
+
+alter table `im_groups_detail`
+  add column `join_policy` varchar(16) not null default 'password' COMMENT '入群方式: open password approval invite' after `current_size`;
+
+CREATE TABLE IF NOT EXISTS `im_group_join_request` (
+  `request_id` bigint auto_increment PRIMARY KEY COMMENT '申请标识',
+  `group_id` bigint not null COMMENT '群号',
+  `user_id` bigint not null COMMENT '申请人账号',
+  `user_nickname` varchar(32) default '' COMMENT '申请人群别称',
+  `message` varchar(255) default '' COMMENT '申请附言',
+  `status` int not null default 0 COMMENT '申请状态: 0 待处理 1 已同意 2 已拒绝',
+  `handler_id` bigint not null default 0 COMMENT '处理人账号',
+  `created_time` timestamp default current_timestamp COMMENT '申请时间',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '处理时间',
+  unique key uk_group_user(group_id,user_id),
+  index i_group_status(group_id,status)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
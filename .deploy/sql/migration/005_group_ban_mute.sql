@@ -0,0 +1,17 @@
+-- 群封禁与禁言: im_groups_users 增加禁言截止时间，新增群封禁表
+-- 被封禁的用户在封禁期内不能重新加入该群
+
+set NAMES 'utf8mb4';
+
+alter table `im_groups_users`
+  add column `mute_until` timestamp null default null COMMENT '禁言截止时间' after `disturb`;
+
+CREATE TABLE IF NOT EXISTS `im_group_ban` (
+  `group_id` bigint not null COMMENT '群号',
+  `user_id` bigint not null COMMENT '被封禁用户账号',
+  `operator_id` bigint not null COMMENT '操作人账号',
+  `reason` varchar(255) default '' COMMENT '封禁原因',
+  `expire_time` timestamp null default null COMMENT '封禁截止时间，为空表示永久',
+  `created_time` timestamp default current_timestamp COMMENT '封禁时间',
+  PRIMARY KEY (`group_id`, `user_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,25 @@
+-- 群公告: 新增群公告表与公告确认表
+-- 每个群同一时间只有一条置顶公告
+
+set NAMES 'utf8mb4';
+
+CREATE TABLE IF NOT EXISTS `im_group_announcement` (
+  `announcement_id` bigint auto_increment PRIMARY KEY COMMENT '公告标识',
+  `group_id` bigint not null COMMENT '群号',
+  `creator_id` bigint not null COMMENT '发布人账号',
+  `title` varchar(64) not null default '' COMMENT '公告标题',
+  `content` varchar(2048) not null default '' COMMENT '公告内容',
+  `pinned` int not null default 0 COMMENT '是否置顶',
+  `need_ack` int not null default 0 COMMENT '是否需要成员确认',
+  `created_time` timestamp default current_timestamp COMMENT '发布时间',
+  `updated_time` timestamp default current_timestamp on update current_timestamp COMMENT '修改时间',
+  `deleted` int default 0 COMMENT '逻辑删除',
+  index i_group_pinned(group_id,pinned)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `im_group_announcement_ack` (
+  `announcement_id` bigint not null COMMENT '公告标识',
+  `user_id` bigint not null COMMENT '确认成员账号',
+  `created_time` timestamp default current_timestamp COMMENT '确认时间',
+  PRIMARY KEY (`announcement_id`, `user_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,13 @@
+-- 单聊申请状态: im_single_chat 增加申请状态，不再用 deleted 表示待处理的邀请
+-- 历史数据中 deleted = 1 的记录无法区分待处理与已删除，统一视为已过期
+
+set NAMES 'utf8mb4';
+
+alter table `im_single_chat`
+  add column `status` int not null default 0 COMMENT '申请状态: 0 待处理 1 已接受 2 已拒绝 3 已取消 4 已过期' after `invitee_disturb`,
+  add index i_invitee_status(invitee_id,status),
+  add index i_inviter_status(inviter_id,status),
+  add index i_status_created(status,created_time);
+
+update `im_single_chat` set `status` = 1 where `deleted` = 0;
+update `im_single_chat` set `status` = 4 where `deleted` = 1;
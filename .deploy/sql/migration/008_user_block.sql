@@ -0,0 +1,12 @@
+-- 用户屏蔽: 新增用户屏蔽表
+-- 被屏蔽的用户不能再发起单聊申请或发送单聊消息，搜索用户时也不会看到屏蔽者
+
+set NAMES 'utf8mb4';
+
+CREATE TABLE IF NOT EXISTS `im_user_block` (
+  `blocker_id` bigint not null COMMENT '屏蔽者账号',
+  `blocked_id` bigint not null COMMENT '被屏蔽者账号',
+  `created_time` timestamp default current_timestamp COMMENT '屏蔽时间',
+  PRIMARY KEY (`blocker_id`, `blocked_id`),
+  index i_blocked(blocked_id)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,20 @@
+-- 联系人标签: 新增用户标签表与标签关联表
+-- 标签可以关联单聊(dialog_type = 1, dialog_id 为 single_id)或群聊(dialog_type = 2, dialog_id 为 group_id)
+
+set NAMES 'utf8mb4';
+
+CREATE TABLE IF NOT EXISTS `im_tag` (
+  `tag_id` bigint auto_increment PRIMARY KEY COMMENT '标签标识',
+  `user_id` bigint not null COMMENT '标签所属用户账号',
+  `tag_name` varchar(32) not null COMMENT '标签名',
+  `created_time` timestamp default current_timestamp COMMENT '创建时间',
+  unique index u_user_tag(user_id,tag_name)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `im_tag_item` (
+  `tag_id` bigint not null COMMENT '标签标识',
+  `dialog_type` int not null COMMENT '会话类型: 1 单聊 2 群聊',
+  `dialog_id` bigint not null COMMENT '单聊标识或群号',
+  `created_time` timestamp default current_timestamp COMMENT '关联时间',
+  PRIMARY KEY (`tag_id`, `dialog_type`, `dialog_id`)
+)ENGINE=InnoDB default CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,28 @@
+-- 时间线类型: 用户收件箱与群时间线的标识分属不同空间，用户账号增长到群号范围后不再互相冲突
+-- 历史数据中时间线标识等于消息所在群号的群消息记录属于读扩散的群时间线
+
+set NAMES 'utf8mb4';
+
+alter table `im_timeline`
+  add column `timeline_type` int not null default 1 COMMENT '时间线类型: 1 用户收件箱 2 群时间线' first,
+  drop primary key,
+  add primary key (`timeline_type`, `timeline_id`, `sequence_id`);
+
+update `im_timeline` it
+  join `im_message` im on im.message_id = it.message_id
+  set it.timeline_type = 2
+  where it.dialog_type = 2 and it.timeline_id = im.receiver;
+
+alter table `im_timeline_sequence`
+  add column `timeline_type` int not null default 1 COMMENT '时间线类型: 1 用户收件箱 2 群时间线' first,
+  drop primary key,
+  add primary key (`timeline_type`, `timeline_id`);
+
+update `im_timeline_sequence` its
+  join `im_groups` ig on ig.group_id = its.timeline_id
+  set its.timeline_type = 2;
+
+alter table `im_group_read`
+  add column `timeline_type` int not null default 1 COMMENT '已读位置所在时间线类型' after `user_id`;
+
+update `im_group_read` set `timeline_type` = 2 where `timeline_id` = `group_id`;
@@ -0,0 +1,13 @@
+-- 时间线序列号水位: 序列号改为在写入消息的事务中锁住水位行分配，水位行需要覆盖已有的全部时间线
+-- 以时间线上已落库的最大序列号补齐水位，保留降级期间 mysql 分配过的更大值
+
+set NAMES 'utf8mb4';
+
+insert into `im_timeline_sequence`(`timeline_type`, `timeline_id`, `sequence_id`)
+  select `it`.`timeline_type`, `it`.`timeline_id`, `it`.`max_sequence_id`
+  from (
+    select `timeline_type`, `timeline_id`, max(`sequence_id`) as `max_sequence_id`
+    from `im_timeline`
+    group by `timeline_type`, `timeline_id`
+  ) as `it`
+on duplicate key update `sequence_id` = greatest(`im_timeline_sequence`.`sequence_id`, `it`.`max_sequence_id`);
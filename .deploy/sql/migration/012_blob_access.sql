@@ -0,0 +1,19 @@
+-- 附件访问控制: 消息记录引用的附件，下载时校验请求者是否在引用该附件的对话中
+-- 头像公开可见，为头像列加索引以便按附件反查
+
+set NAMES 'utf8mb4';
+
+alter table `im_message`
+  add column `blob_id` varchar(64) not null default '' COMMENT '媒体消息引用的附件' after `content`,
+  add index i_blob(blob_id);
+
+update `im_message` im
+  join `im_message_type` imt on imt.type_id = im.type
+  set im.blob_id = coalesce(json_unquote(json_extract(im.content, '$.blobId')), '')
+  where imt.type_name in ('image', 'audio', 'video') and json_valid(im.content);
+
+alter table `im_users_detail`
+  add index i_avatar(avatar);
+
+alter table `im_groups_detail`
+  add index i_group_avatar(group_avatar);
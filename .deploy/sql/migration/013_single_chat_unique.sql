@@ -0,0 +1,16 @@
+-- 单聊记录唯一: single_id 由双方账号对称生成，两人之间只保留一条记录，反向申请复用该记录
+-- 历史数据中双向各有一条记录时，保留已建立的记录，否则保留较早的记录
+
+set NAMES 'utf8mb4';
+
+delete isc
+from `im_single_chat` isc
+join `im_single_chat` other
+  on other.single_id = isc.single_id and other.inviter_id = isc.invitee_id and other.invitee_id = isc.inviter_id
+where
+  (isc.deleted = 1 and other.deleted = 0)
+  or (isc.deleted = other.deleted and isc.created_time > other.created_time)
+  or (isc.deleted = other.deleted and isc.created_time = other.created_time and isc.inviter_id > other.inviter_id);
+
+alter table `im_single_chat`
+  add unique index u_single(single_id);
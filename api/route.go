@@ -1,18 +1,26 @@
 package api
 
 import (
+	"context"
 	"log"
 
 	"github.com/wendisx/gorchat/handler"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/task"
 	"github.com/wendisx/gorchat/model"
 	"github.com/wendisx/gorchat/repository"
 	"github.com/wendisx/gorchat/usecase"
 )
 
 const (
-	GROUP_USER   = "/user"
-	GROUP_SINGLE = "/single"
-	GROUP_GROUP  = "/group"
+	GROUP_USER         = "/user"
+	GROUP_SINGLE       = "/single"
+	GROUP_GROUP        = "/group"
+	GROUP_WS           = "/ws"
+	GROUP_MESSAGE      = "/message"
+	GROUP_FILE         = "/file"
+	GROUP_TAG          = "/tag"
+	GROUP_CONVERSATION = "/conversation"
 )
 
 func SetupRoute(dependency *model.Dependency) {
@@ -20,6 +28,17 @@ func SetupRoute(dependency *model.Dependency) {
 	registerUserRoute(dependency)
 	registerSingleRoute(dependency)
 	registerGroupRoute(dependency)
+	registerWsRoute(dependency)
+	registerMessageRoute(dependency)
+	registerFileRoute(dependency)
+	registerTagRoute(dependency)
+	registerConversationRoute(dependency)
+}
+
+func newAuthorizer(dep *model.Dependency) usecase.Authorizer {
+	singleRepo := repository.NewSingleRepository(dep.Database, dep.Logger)
+	groupRepo := repository.NewGroupRepository(dep.Database, dep.Logger)
+	return usecase.NewAuthorizer(singleRepo, groupRepo)
 }
 
 func registerUserRoute(dep *model.Dependency) {
@@ -27,15 +46,21 @@ func registerUserRoute(dep *model.Dependency) {
 	g := dep.Echo.Group(GROUP_USER)
 
 	userRepo := repository.NewUserRepository(dep.Database, dep.Logger)
-	userCase := usecase.NewUserUsecase(userRepo)
-	userHandler := handler.NewUserHandler(userCase, dep.Response)
+	groupRepo := repository.NewGroupRepository(dep.Database, dep.Logger)
+	blockRepo := repository.NewBlockRepository(dep.Database, dep.Logger)
+	authorizer := newAuthorizer(dep)
+	userCase := usecase.NewUserUsecase(userRepo, groupRepo, blockRepo, dep.BlobStore, authorizer)
+	userHandler := handler.NewUserHandler(userCase, dep.Response, dep.Session)
 
 	g.POST("/signup", userHandler.Signup, dep.MiddleWare.ValidatorMiddleware(&model.SignupReq{}))
-	g.GET("/login", userHandler.Login, dep.MiddleWare.ValidatorMiddleware(&model.LoginReq{}), dep.MiddleWare.SessionCheckMiddleware(true))
-	g.PUT("/update", userHandler.UpdateInfo, dep.MiddleWare.ValidatorMiddleware(&model.UpdateInfoReq{}), dep.MiddleWare.SessionCheckMiddleware(false))
-	g.DELETE("/delete", userHandler.Delete, dep.MiddleWare.SessionCheckMiddleware(false))
-	g.GET("/detail", userHandler.GetUserdetail, dep.MiddleWare.SessionCheckMiddleware(false))
-	g.GET("/search", userHandler.SearchUser, dep.MiddleWare.ValidatorMiddleware(&model.SearchUserReq{}), dep.MiddleWare.SessionCheckMiddleware(false))
+	g.GET("/login", userHandler.Login, dep.MiddleWare.ValidatorMiddleware(&model.LoginReq{}))
+	g.PUT("/update", userHandler.UpdateInfo, dep.MiddleWare.ValidatorMiddleware(&model.UpdateInfoReq{}), dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+	g.DELETE("/delete", userHandler.Delete, dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+	g.GET("/detail", userHandler.GetUserdetail, dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+	g.GET("/search", userHandler.SearchUser, dep.MiddleWare.ValidatorMiddleware(&model.SearchUserReq{}), dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+	g.POST("/block", userHandler.Block, dep.MiddleWare.ValidatorMiddleware(&model.BlockUserReq{}), dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+	g.DELETE("/block", userHandler.Unblock, dep.MiddleWare.ValidatorMiddleware(&model.BlockUserReq{}), dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+	g.GET("/blocks", userHandler.GetBlocks, dep.MiddleWare.ValidatorMiddleware(&model.GetBlocksReq{}), dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
 }
 
 func registerSingleRoute(dep *model.Dependency) {
@@ -43,10 +68,20 @@ func registerSingleRoute(dep *model.Dependency) {
 	g := dep.Echo.Group(GROUP_SINGLE)
 
 	singleRepo := repository.NewSingleRepository(dep.Database, dep.Logger)
-	singleCase := usecase.NewSingleUsercase(singleRepo)
+	blockRepo := repository.NewBlockRepository(dep.Database, dep.Logger)
+	authorizer := newAuthorizer(dep)
+	singleCase := usecase.NewSingleUsercase(singleRepo, blockRepo, authorizer)
 	singleHandler := handler.NewSingleHandler(singleCase, dep.Response)
 
-	g.Use(dep.MiddleWare.SessionCheckMiddleware(false))
+	// task -- 超时未处理的单聊申请标记为过期
+	requestTTL := dep.Env.Duration(constant.SINGLE_REQUEST_TTL, constant.DEFAULT_SINGLE_REQUEST_TTL)
+	task.Every(context.Background(), "single request expire", dep.Env.Duration(constant.SINGLE_GC_INTERVAL, constant.DEFAULT_SINGLE_GC_INTERVAL), func(ctx context.Context) {
+		if n, err := singleCase.ExpireRequests(ctx, requestTTL); err == nil && n > 0 {
+			log.Printf("[task] -- (single request expire) expired: %d", n)
+		}
+	})
+
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
 
 	g.POST("/invite", singleHandler.Invite, dep.MiddleWare.ValidatorMiddleware(&model.InviteReq{}))
 	g.PATCH("/accept", singleHandler.Accept, dep.MiddleWare.ValidatorMiddleware(&model.AcceptReq{}))
@@ -54,6 +89,11 @@ func registerSingleRoute(dep *model.Dependency) {
 	g.PATCH("/setDisturb", singleHandler.UpdateDisturb, dep.MiddleWare.ValidatorMiddleware(&model.UpdateDisturbReq{}))
 	g.GET("/detail", singleHandler.GetDetail, dep.MiddleWare.ValidatorMiddleware(&model.GetDetailReq{}))
 	g.DELETE("/delete", singleHandler.Delete, dep.MiddleWare.ValidatorMiddleware(&model.DeleteReq{}))
+	g.GET("/list", singleHandler.GetList, dep.MiddleWare.ValidatorMiddleware(&model.GetContactsReq{}))
+	g.GET("/requests/incoming", singleHandler.GetIncoming, dep.MiddleWare.ValidatorMiddleware(&model.GetRequestsReq{}))
+	g.GET("/requests/outgoing", singleHandler.GetOutgoing, dep.MiddleWare.ValidatorMiddleware(&model.GetRequestsReq{}))
+	g.PATCH("/decline", singleHandler.Decline, dep.MiddleWare.ValidatorMiddleware(&model.RequestReq{}))
+	g.PATCH("/cancel", singleHandler.Cancel, dep.MiddleWare.ValidatorMiddleware(&model.RequestReq{}))
 }
 
 func registerGroupRoute(dep *model.Dependency) {
@@ -61,13 +101,18 @@ func registerGroupRoute(dep *model.Dependency) {
 	g := dep.Echo.Group(GROUP_GROUP)
 
 	groupRepo := repository.NewGroupRepository(dep.Database, dep.Logger)
-	groupUcase := usecase.NewGroupUsecase(groupRepo)
+	inviteRepo := repository.NewInviteRepository(dep.RedisClient, dep.Logger)
+	requestRepo := repository.NewJoinRequestRepository(dep.Database, dep.Logger)
+	announceRepo := repository.NewAnnouncementRepository(dep.Database, dep.Logger)
+	authorizer := newAuthorizer(dep)
+	groupUcase := usecase.NewGroupUsecase(groupRepo, inviteRepo, requestRepo, announceRepo, dep.BlobStore, dep.Hub, authorizer)
 	groupHandler := handler.NewGroupHandler(groupUcase, dep.Response)
 
-	g.Use(dep.MiddleWare.SessionCheckMiddleware(false))
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
 
 	g.POST("/create", groupHandler.CreateGroup, dep.MiddleWare.ValidatorMiddleware(&model.CreateGroupReq{}))
 	g.POST("/join", groupHandler.JoinGroup, dep.MiddleWare.ValidatorMiddleware(&model.JoinGroupReq{}))
+	g.POST("/joinByCode", groupHandler.JoinByCode, dep.MiddleWare.ValidatorMiddleware(&model.JoinByCodeReq{}))
 	g.PATCH("/setRole", groupHandler.UpdateGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.UpdateGroupUserReq{}))
 	g.PATCH("/setDisturb", groupHandler.UpdateGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.UpdateGroupUserReq{}))
 	g.PATCH("/setUserNickname", groupHandler.UpdateGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.UpdateGroupUserReq{}))
@@ -78,4 +123,115 @@ func registerGroupRoute(dep *model.Dependency) {
 	g.GET("/searchUsers", groupHandler.SearchGroupUsers, dep.MiddleWare.ValidatorMiddleware(&model.SearchGroupUsersReq{}))
 	g.DELETE("/delete", groupHandler.DeleteGroup)
 	g.DELETE("/deleteUser", groupHandler.DeleteGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.DeleteGroupUserReq{}))
+	g.PATCH("/transfer", groupHandler.TransferGroup, dep.MiddleWare.ValidatorMiddleware(&model.TransferGroupReq{}))
+	g.GET("/permissions", groupHandler.GetPermissions, dep.MiddleWare.ValidatorMiddleware(&model.GetGroupPermissionReq{}))
+	g.POST("/invite", groupHandler.CreateInvite, dep.MiddleWare.ValidatorMiddleware(&model.CreateInviteReq{}))
+	g.GET("/invites", groupHandler.GetInvites, dep.MiddleWare.ValidatorMiddleware(&model.GetInvitesReq{}))
+	g.DELETE("/invite", groupHandler.RevokeInvite, dep.MiddleWare.ValidatorMiddleware(&model.RevokeInviteReq{}))
+	g.PATCH("/setJoinPolicy", groupHandler.SetJoinPolicy, dep.MiddleWare.ValidatorMiddleware(&model.SetJoinPolicyReq{}))
+	g.GET("/requests", groupHandler.GetJoinRequests, dep.MiddleWare.ValidatorMiddleware(&model.GetJoinRequestsReq{}))
+	g.PATCH("/approveRequest", groupHandler.ApproveJoinRequest, dep.MiddleWare.ValidatorMiddleware(&model.HandleJoinRequestReq{}))
+	g.PATCH("/rejectRequest", groupHandler.RejectJoinRequest, dep.MiddleWare.ValidatorMiddleware(&model.HandleJoinRequestReq{}))
+	g.POST("/ban", groupHandler.BanGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.BanGroupUserReq{}))
+	g.DELETE("/ban", groupHandler.UnbanGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.UnbanGroupUserReq{}))
+	g.GET("/bans", groupHandler.GetGroupBans, dep.MiddleWare.ValidatorMiddleware(&model.GetGroupBansReq{}))
+	g.PATCH("/mute", groupHandler.MuteGroupUser, dep.MiddleWare.ValidatorMiddleware(&model.MuteGroupUserReq{}))
+	g.GET("/detail", groupHandler.GetGroupDetail, dep.MiddleWare.ValidatorMiddleware(&model.GetGroupDetailReq{}))
+	g.POST("/announcement", groupHandler.CreateAnnouncement, dep.MiddleWare.ValidatorMiddleware(&model.CreateAnnouncementReq{}))
+	g.PUT("/announcement", groupHandler.UpdateAnnouncement, dep.MiddleWare.ValidatorMiddleware(&model.UpdateAnnouncementReq{}))
+	g.DELETE("/announcement", groupHandler.DeleteAnnouncement, dep.MiddleWare.ValidatorMiddleware(&model.AnnouncementReq{}))
+	g.GET("/announcements", groupHandler.GetAnnouncements, dep.MiddleWare.ValidatorMiddleware(&model.GetAnnouncementsReq{}))
+	g.PATCH("/announcement/ack", groupHandler.AckAnnouncement, dep.MiddleWare.ValidatorMiddleware(&model.AnnouncementReq{}))
+}
+
+func registerWsRoute(dep *model.Dependency) {
+	defer log.Printf("[init] -- (api/route/ws) status: success")
+	g := dep.Echo.Group(GROUP_WS)
+
+	wsHandler := handler.NewWsHandler(dep.Hub, dep.Logger, dep.Response)
+
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+
+	g.GET("", wsHandler.Connect)
+}
+
+func registerMessageRoute(dep *model.Dependency) {
+	defer log.Printf("[init] -- (api/route/message) status: success")
+	g := dep.Echo.Group(GROUP_MESSAGE)
+
+	seqRepo := repository.NewSequenceRepository(dep.Database, dep.Logger)
+	messageRepo := repository.NewMessageRepository(dep.Database, seqRepo, dep.Logger)
+	groupRepo := repository.NewGroupRepository(dep.Database, dep.Logger)
+	blockRepo := repository.NewBlockRepository(dep.Database, dep.Logger)
+	convRepo := repository.NewConversationRepository(dep.Database, dep.RedisClient, dep.Logger)
+	recallWindow := dep.Env.Duration(constant.MESSAGE_RECALL_WINDOW, constant.DEFAULT_MESSAGE_RECALL_WINDOW)
+	messageCase := usecase.NewMessageUsecase(messageRepo, groupRepo, blockRepo, convRepo, dep.Hub, recallWindow, dep.Validator, dep.BlobStore)
+	messageHandler := handler.NewMessageHandler(messageCase, dep.Response)
+
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+
+	g.POST("/single/send", messageHandler.SendSingle, dep.MiddleWare.ValidatorMiddleware(&model.SendSingleReq{}))
+	g.POST("/group/send", messageHandler.SendGroup, dep.MiddleWare.ValidatorMiddleware(&model.SendGroupReq{}))
+	g.POST("/sync", messageHandler.Sync, dep.MiddleWare.ValidatorMiddleware(&model.SyncReq{}))
+	g.PATCH("/read", messageHandler.MarkRead, dep.MiddleWare.ValidatorMiddleware(&model.MarkReadReq{}))
+	g.PATCH("/recall", messageHandler.Recall, dep.MiddleWare.ValidatorMiddleware(&model.RecallReq{}))
+}
+
+func registerFileRoute(dep *model.Dependency) {
+	defer log.Printf("[init] -- (api/route/file) status: success")
+	g := dep.Echo.Group(GROUP_FILE)
+
+	uploadRepo := repository.NewUploadRepository(dep.RedisClient, dep.Logger)
+	blobRepo := repository.NewBlobRepository(dep.Database, dep.Logger)
+	maxSize := dep.Env.Int64(constant.UPLOAD_MAX_SIZE, constant.DEFAULT_UPLOAD_MAX_SIZE)
+	blobSize := dep.Env.Int64(constant.BLOB_MAX_SIZE, constant.DEFAULT_BLOB_MAX_SIZE)
+	uploadTTL := dep.Env.Duration(constant.UPLOAD_TTL, constant.DEFAULT_UPLOAD_TTL)
+	fileCase := usecase.NewFileUsecase(dep.BlobStore, dep.PartStore, uploadRepo, blobRepo, maxSize, blobSize, uploadTTL)
+	fileHandler := handler.NewFileHandler(fileCase, dep.Response)
+
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+
+	g.POST("/upload", fileHandler.Upload, dep.MiddleWare.BodyLimitMiddleware(maxSize+handler.UPLOAD_FORM_OVERHEAD))
+	g.POST("/multipart", fileHandler.InitUpload, dep.MiddleWare.ValidatorMiddleware(&model.InitUploadReq{}))
+	g.PUT("/multipart/:uploadId/:part", fileHandler.UploadPart)
+	g.GET("/multipart/:uploadId", fileHandler.GetUpload)
+	g.POST("/multipart/:uploadId/complete", fileHandler.CompleteUpload)
+	g.GET("/:id", fileHandler.Download)
+}
+
+func registerTagRoute(dep *model.Dependency) {
+	defer log.Printf("[init] -- (api/route/tag) status: success")
+	g := dep.Echo.Group(GROUP_TAG)
+
+	tagRepo := repository.NewTagRepository(dep.Database, dep.Logger)
+	groupRepo := repository.NewGroupRepository(dep.Database, dep.Logger)
+	authorizer := newAuthorizer(dep)
+	tagCase := usecase.NewTagUsecase(tagRepo, groupRepo, authorizer)
+	tagHandler := handler.NewTagHandler(tagCase, dep.Response)
+
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+
+	g.POST("/create", tagHandler.CreateTag, dep.MiddleWare.ValidatorMiddleware(&model.CreateTagReq{}))
+	g.PUT("/update", tagHandler.UpdateTag, dep.MiddleWare.ValidatorMiddleware(&model.UpdateTagReq{}))
+	g.DELETE("/delete", tagHandler.DeleteTag, dep.MiddleWare.ValidatorMiddleware(&model.TagReq{}))
+	g.GET("/list", tagHandler.GetTags)
+	g.POST("/item", tagHandler.AddItem, dep.MiddleWare.ValidatorMiddleware(&model.TagItemReq{}))
+	g.DELETE("/item", tagHandler.RemoveItem, dep.MiddleWare.ValidatorMiddleware(&model.TagItemReq{}))
+}
+
+func registerConversationRoute(dep *model.Dependency) {
+	defer log.Printf("[init] -- (api/route/conversation) status: success")
+	g := dep.Echo.Group(GROUP_CONVERSATION)
+
+	convRepo := repository.NewConversationRepository(dep.Database, dep.RedisClient, dep.Logger)
+	seqRepo := repository.NewSequenceRepository(dep.Database, dep.Logger)
+	messageRepo := repository.NewMessageRepository(dep.Database, seqRepo, dep.Logger)
+	groupRepo := repository.NewGroupRepository(dep.Database, dep.Logger)
+	conversationCase := usecase.NewConversationUsecase(convRepo, messageRepo, groupRepo)
+	conversationHandler := handler.NewConversationHandler(conversationCase, dep.Response)
+
+	g.Use(dep.MiddleWare.SessionCheckMiddleware(false), dep.MiddleWare.PrincipalMiddleware())
+
+	g.GET("/list", conversationHandler.GetList, dep.MiddleWare.ValidatorMiddleware(&model.GetConversationsReq{}))
+	g.PATCH("/pin", conversationHandler.Pin, dep.MiddleWare.ValidatorMiddleware(&model.PinConversationReq{}))
 }
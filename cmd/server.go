@@ -5,6 +5,7 @@ import (
 	"fmt"
 	lg "log"
 	"net/http"
+	"path/filepath"
 
 	"github.com/labstack/echo/v4"
 	"github.com/wendisx/gorchat/config"
@@ -13,7 +14,10 @@ import (
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
 	"github.com/wendisx/gorchat/internal/redistore"
+	"github.com/wendisx/gorchat/internal/storage"
+	"github.com/wendisx/gorchat/internal/task"
 	"github.com/wendisx/gorchat/internal/validator"
+	"github.com/wendisx/gorchat/internal/ws"
 	"github.com/wendisx/gorchat/model"
 	"github.com/wendisx/gorchat/repository"
 )
@@ -32,8 +36,12 @@ func startup() {
 func globalErrorHandler(e error, c echo.Context) {
 	var err error
 	if derr, ok := e.(*model.DError); ok {
+		status := http.StatusInternalServerError
+		if derr.Code == constant.ErrPermissionDenied {
+			status = http.StatusForbidden
+		}
 		err = c.JSON(
-			http.StatusInternalServerError,
+			status,
 			map[string]any{
 				"code":    int(derr.Code),
 				"message": derr.Message,
@@ -88,14 +96,33 @@ func setup() (*echo.Echo, config.Env, *model.Dependency, string) {
 	// middleware -- 中间件初始化
 	validator := validator.NewValidator()
 	md := middleware.NewMiddleware(validator, rstore)
+	// ws -- 在线连接注册表
+	hub := ws.NewHub()
+	// storage -- 附件存储以及分片上传的临时分片
+	blobRoot := env.String(constant.BLOB_ROOT, constant.DEFAULT_BLOB_ROOT)
+	blobStore := storage.NewLocalStore(blobRoot, env.Int64(constant.BLOB_MAX_SIZE, constant.DEFAULT_BLOB_MAX_SIZE))
+	partStore := storage.NewLocalPartStore(filepath.Join(blobRoot, "parts"))
+	// task -- 清理超时未完成的分片上传
+	uploadTTL := env.Duration(constant.UPLOAD_TTL, constant.DEFAULT_UPLOAD_TTL)
+	task.Every(context.Background(), "upload gc", env.Duration(constant.UPLOAD_GC_INTERVAL, constant.DEFAULT_UPLOAD_GC_INTERVAL), func(ctx context.Context) {
+		if n := partStore.Sweep(uploadTTL); n > 0 {
+			lg.Printf("[task] -- (upload gc) removed: %d", n)
+		}
+	})
 
 	dep := &model.Dependency{
 		Echo:        e,
+		Env:         env,
 		Database:    db,
 		Logger:      sugar,
 		RedisClient: rdb,
 		Response:    res,
 		MiddleWare:  md,
+		Session:     rstore,
+		Validator:   validator,
+		Hub:         hub,
+		BlobStore:   blobStore,
+		PartStore:   partStore,
 	}
 
 	// echo -- 服务监听地址
@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/wendisx/gorchat/internal/constant"
@@ -19,3 +21,40 @@ func NewEnv(file string) Env {
 	}
 	return env
 }
+
+// 读取时长配置，未配置或格式错误时使用默认值
+func (env Env) Duration(key, def string) time.Duration {
+	value, ok := env[key]
+	if !ok || value == "" {
+		value = def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("[init] -- (config/dotenv) %s invalid, use default: %s", key, def)
+		d, _ = time.ParseDuration(def)
+	}
+	return d
+}
+
+// 读取字符串配置，未配置时使用默认值
+func (env Env) String(key, def string) string {
+	value, ok := env[key]
+	if !ok || value == "" {
+		return def
+	}
+	return value
+}
+
+// 读取整数配置，未配置或格式错误时使用默认值
+func (env Env) Int64(key, def string) int64 {
+	value, ok := env[key]
+	if !ok || value == "" {
+		value = def
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("[init] -- (config/dotenv) %s invalid, use default: %s", key, def)
+		n, _ = strconv.ParseInt(def, 10, 64)
+	}
+	return n
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/validator"
 )
@@ -14,6 +15,8 @@ import (
 type Middleware interface {
 	ValidatorMiddleware(v any) echo.MiddlewareFunc
 	SessionCheckMiddleware(allowNew bool) echo.MiddlewareFunc
+	PrincipalMiddleware() echo.MiddlewareFunc
+	BodyLimitMiddleware(limit int64) echo.MiddlewareFunc
 }
 
 type middleware struct {
@@ -78,3 +81,38 @@ func (md *middleware) SessionCheckMiddleware(allowNew bool) echo.MiddlewareFunc
 		}
 	}
 }
+
+// 从 session 中取出登录身份放入 echo.Context，未登录的 session 视为未鉴权
+func (md *middleware) PrincipalMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			session, err := md.store.Get(c.Request(), constant.SESSION_KEY)
+			if err != nil || session.IsNew {
+				return echo.NewHTTPError(http.StatusUnauthorized, constant.MsgNotAuthenticate)
+			}
+			principal, ok := auth.FromSession(session)
+			if !ok {
+				log.Printf("[middleware] -- (principal) status: anonymous\n")
+				return echo.NewHTTPError(http.StatusUnauthorized, constant.MsgNotAuthenticate)
+			}
+			auth.SetPrincipal(c, principal)
+			log.Printf("[middleware] -- (principal) user: %d\n", principal.UserId)
+			return next(c)
+		}
+	}
+}
+
+// 限制请求体大小，声明的长度超限直接拒绝，其余在读取超限时中断，避免解析前整个请求体落盘
+func (md *middleware) BodyLimitMiddleware(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				log.Printf("[middleware] -- (body limit) length: %d\n", req.ContentLength)
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, constant.MsgFileTooLarge)
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
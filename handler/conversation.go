@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/usecase"
+)
+
+type ConversationHandler interface {
+	GetList(e echo.Context) error
+	Pin(e echo.Context) error
+}
+
+type conversationHandler struct {
+	ucase  usecase.ConversationUsecase
+	logger log.Logger
+	res    model.Response
+}
+
+func NewConversationHandler(ucase usecase.ConversationUsecase, res model.Response) ConversationHandler {
+	return &conversationHandler{
+		ucase:  ucase,
+		logger: ucase.GetLogger(),
+		res:    res,
+	}
+}
+
+func (h *conversationHandler) GetList(e echo.Context) error {
+	getConversationsReq, ok := e.Get("body").(*model.GetConversationsReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	conversations, err := h.ucase.Conversations(principal.UserId, getConversationsReq.TagId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgConversationListSuccess, conversations)
+}
+
+func (h *conversationHandler) Pin(e echo.Context) error {
+	pinConversationReq, ok := e.Get("body").(*model.PinConversationReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.Pin(principal.UserId, pinConversationReq.DialogType, pinConversationReq.DialogId, pinConversationReq.Pinned)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgConversationPinSuccess, nil)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/usecase"
+)
+
+const (
+	UPLOAD_FORM_FIELD    = "file"
+	UPLOAD_FORM_OVERHEAD = 1 << 20 // 表单上传时 multipart 边界与字段头的余量
+)
+
+// 允许在浏览器中直接展示的附件类型，其余类型(如 html、svg)一律作为附件下载，避免在站点源下执行
+var inlineMimeTypes = map[string]struct{}{
+	"image/png":  {},
+	"image/jpeg": {},
+	"image/gif":  {},
+	"image/webp": {},
+	"image/bmp":  {},
+	"audio/mpeg": {},
+	"audio/wave": {},
+	"audio/aiff": {},
+	"video/mp4":  {},
+	"video/webm": {},
+}
+
+type FileHandler interface {
+	Upload(e echo.Context) error
+	Download(e echo.Context) error
+	InitUpload(e echo.Context) error
+	UploadPart(e echo.Context) error
+	GetUpload(e echo.Context) error
+	CompleteUpload(e echo.Context) error
+}
+
+type fileHandler struct {
+	ucase  usecase.FileUsecase
+	logger log.Logger
+	res    model.Response
+}
+
+func NewFileHandler(ucase usecase.FileUsecase, res model.Response) FileHandler {
+	return &fileHandler{
+		ucase:  ucase,
+		logger: ucase.GetLogger(),
+		res:    res,
+	}
+}
+
+func (h *fileHandler) Upload(e echo.Context) error {
+	fileHeader, err := e.FormFile(UPLOAD_FORM_FIELD)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return h.res.Fail(e, http.StatusRequestEntityTooLarge, int(constant.ErrFileTooLarge), constant.MsgFileTooLarge)
+	}
+	if err != nil {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	defer file.Close()
+	blob, err := h.ucase.Upload(file, fileHeader.Size)
+	if err != nil {
+		return err
+	}
+	uploadRes := &model.UploadRes{
+		BlobId:   blob.Id,
+		Size:     blob.Size,
+		MimeType: blob.MimeType,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgFileUploadSuccess, uploadRes)
+}
+
+func (h *fileHandler) Download(e echo.Context) error {
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	reader, blob, err := h.ucase.Open(principal.UserId, e.Param("id"))
+	if err != nil {
+		return h.res.Fail(e, http.StatusNotFound, int(constant.ErrFileNotFound), constant.MsgFileNotFound)
+	}
+	defer reader.Close()
+	e.Response().Header().Set(echo.HeaderContentType, blob.MimeType)
+	e.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+	if _, ok := inlineMimeTypes[blob.MimeType]; ok {
+		e.Response().Header().Set(echo.HeaderContentDisposition, "inline")
+	} else {
+		e.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=\""+blob.Id+"\"")
+	}
+	// 内容寻址，同一 id 的内容永远不变
+	e.Response().Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	http.ServeContent(e.Response(), e.Request(), blob.Id, time.Time{}, reader)
+	return nil
+}
+
+func (h *fileHandler) InitUpload(e echo.Context) error {
+	initUploadReq, ok := e.Get("body").(*model.InitUploadReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	upload := &model.Upload{
+		Size:      initUploadReq.Size,
+		PartCount: initUploadReq.PartCount,
+	}
+	expireTime, err := h.ucase.InitUpload(upload)
+	if err != nil {
+		return err
+	}
+	initUploadRes := &model.InitUploadRes{
+		UploadId:   upload.UploadId,
+		Size:       upload.Size,
+		PartCount:  upload.PartCount,
+		ExpireTime: expireTime,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgUploadInitSuccess, initUploadRes)
+}
+
+// 分片内容为原始请求体
+func (h *fileHandler) UploadPart(e echo.Context) error {
+	part, err := strconv.Atoi(e.Param("part"))
+	if err != nil {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	uploadId := e.Param("uploadId")
+	uploadPart, err := h.ucase.UploadPart(uploadId, part, e.Request().Body)
+	if err != nil {
+		return err
+	}
+	uploadPartRes := &model.UploadPartRes{
+		UploadId: uploadId,
+		Part:     uploadPart.Part,
+		Size:     uploadPart.Size,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgUploadPartSuccess, uploadPartRes)
+}
+
+func (h *fileHandler) GetUpload(e echo.Context) error {
+	upload, parts, err := h.ucase.GetUpload(e.Param("uploadId"))
+	if err != nil {
+		return err
+	}
+	uploadPartsRes := &model.UploadPartsRes{
+		UploadId:  upload.UploadId,
+		Size:      upload.Size,
+		PartCount: upload.PartCount,
+		Parts:     parts,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgUploadGetSuccess, uploadPartsRes)
+}
+
+func (h *fileHandler) CompleteUpload(e echo.Context) error {
+	blob, err := h.ucase.CompleteUpload(e.Param("uploadId"))
+	if err != nil {
+		return err
+	}
+	uploadRes := &model.UploadRes{
+		BlobId:   blob.Id,
+		Size:     blob.Size,
+		MimeType: blob.MimeType,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgFileUploadSuccess, uploadRes)
+}
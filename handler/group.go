@@ -3,8 +3,10 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
 	"github.com/wendisx/gorchat/model"
@@ -21,6 +23,26 @@ type GroupHandler interface {
 	GetGroupUsers(e echo.Context) error
 	DeleteGroup(e echo.Context) error
 	DeleteGroupUser(e echo.Context) error
+	GetPermissions(e echo.Context) error
+	TransferGroup(e echo.Context) error
+	CreateInvite(e echo.Context) error
+	GetInvites(e echo.Context) error
+	RevokeInvite(e echo.Context) error
+	JoinByCode(e echo.Context) error
+	SetJoinPolicy(e echo.Context) error
+	GetJoinRequests(e echo.Context) error
+	ApproveJoinRequest(e echo.Context) error
+	RejectJoinRequest(e echo.Context) error
+	BanGroupUser(e echo.Context) error
+	UnbanGroupUser(e echo.Context) error
+	GetGroupBans(e echo.Context) error
+	MuteGroupUser(e echo.Context) error
+	GetGroupDetail(e echo.Context) error
+	CreateAnnouncement(e echo.Context) error
+	UpdateAnnouncement(e echo.Context) error
+	DeleteAnnouncement(e echo.Context) error
+	GetAnnouncements(e echo.Context) error
+	AckAnnouncement(e echo.Context) error
 }
 
 type groupHandler struct {
@@ -42,12 +64,16 @@ func (h *groupHandler) CreateGroup(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	groupBasic := &model.GroupBasic{
 		GroupName:     createGroupReq.GroupName,
 		GroupNickname: createGroupReq.GroupName,
 		GroupPassword: createGroupReq.GroupPassword,
 		GroupMaxSize:  createGroupReq.GroupMaxSize,
-		UserId:        createGroupReq.UserId,
+		UserId:        principal.UserId,
 		UserNickname:  createGroupReq.UserNickname,
 	}
 	err := h.ucase.GroupCreate(groupBasic)
@@ -68,14 +94,18 @@ func (h *groupHandler) JoinGroup(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	groupToUser := &model.GroupToUser{
 		GroupId:      joinGroupReq.GroupId,
-		UserId:       joinGroupReq.UserId,
+		UserId:       principal.UserId,
 		UserNickname: joinGroupReq.UserNickname,
 		UserDisturb:  joinGroupReq.UserDisturb,
 		UserRoleId:   3,
 	}
-	err := h.ucase.GroupJoin(groupToUser)
+	pending, err := h.ucase.GroupJoin(groupToUser, joinGroupReq.GroupPassword, joinGroupReq.Message)
 	if err != nil {
 		return err
 	}
@@ -83,6 +113,10 @@ func (h *groupHandler) JoinGroup(e echo.Context) error {
 		GroupId:      groupToUser.GroupId,
 		UserNickname: groupToUser.UserNickname,
 		UserDisturb:  groupToUser.UserDisturb,
+		Pending:      pending,
+	}
+	if pending {
+		return h.res.Success(e, http.StatusOK, constant.MsgGroupApplySuccess, joinGroupRes)
 	}
 	return h.res.Success(e, http.StatusOK, constant.MsgGroupJoinSuccess, joinGroupRes)
 }
@@ -92,6 +126,10 @@ func (h *groupHandler) UpdateGroup(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	group := &model.Group{
 		GroupId:       updateGroupReq.GroupId,
 		GroupName:     updateGroupReq.GroupName,
@@ -99,7 +137,7 @@ func (h *groupHandler) UpdateGroup(e echo.Context) error {
 		GroupMaxSize:  updateGroupReq.GroupMaxSize,
 		GroupAvatar:   updateGroupReq.GroupAvatar,
 	}
-	err := h.ucase.GroupUpdate(group)
+	err := h.ucase.GroupUpdate(principal.UserId, group)
 	if err != nil {
 		return err
 	}
@@ -111,6 +149,14 @@ func (h *groupHandler) UpdateGroupUser(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	// 未指定成员时设置自己
+	if updateGroupUserReq.SetUserId == 0 {
+		updateGroupUserReq.SetUserId = principal.UserId
+	}
 	groupToUser := &model.GroupToUser{
 		GroupId:            updateGroupUserReq.GroupId,
 		GroupNickname:      updateGroupUserReq.SetGroupNickname,
@@ -124,7 +170,7 @@ func (h *groupHandler) UpdateGroupUser(e echo.Context) error {
 		IsSetUserNickname:  updateGroupUserReq.IsSetUserNickname,
 		IsSetGroupNickname: updateGroupUserReq.IsSetGroupNickname,
 	}
-	err := h.ucase.GroupUpdateUser(groupToUser)
+	err := h.ucase.GroupUpdateUser(principal.UserId, groupToUser)
 	if err != nil {
 		return err
 	}
@@ -195,7 +241,11 @@ func (h *groupHandler) DeleteGroup(e echo.Context) error {
 	if err != nil {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
-	err = h.ucase.GroupDelete(int64(groupId))
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err = h.ucase.GroupDelete(principal.UserId, int64(groupId))
 	if err != nil {
 		return err
 	}
@@ -207,9 +257,395 @@ func (h *groupHandler) DeleteGroupUser(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
-	err := h.ucase.GroupDeleteUser(deleteGroupUserReq.GroupId, deleteGroupUserReq.UserId)
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	// 未指定成员时视为退群
+	if deleteGroupUserReq.UserId == 0 {
+		deleteGroupUserReq.UserId = principal.UserId
+	}
+	err := h.ucase.GroupDeleteUser(principal.UserId, deleteGroupUserReq.GroupId, deleteGroupUserReq.UserId)
 	if err != nil {
 		return err
 	}
 	return h.res.Success(e, http.StatusOK, constant.MsgGroupDeleteUserSuccess, nil)
 }
+
+func (h *groupHandler) GetPermissions(e echo.Context) error {
+	getGroupPermissionReq, ok := e.Get("body").(*model.GetGroupPermissionReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	groupPermissionRes, err := h.ucase.GroupPermissions(principal.UserId, getGroupPermissionReq.GroupId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupPermissionSuccess, groupPermissionRes)
+}
+
+func (h *groupHandler) TransferGroup(e echo.Context) error {
+	transferGroupReq, ok := e.Get("body").(*model.TransferGroupReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupTransfer(principal.UserId, transferGroupReq.GroupId, transferGroupReq.SuccessorId, transferGroupReq.DemoteRole)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupTransferSuccess, nil)
+}
+
+func (h *groupHandler) CreateInvite(e echo.Context) error {
+	createInviteReq, ok := e.Get("body").(*model.CreateInviteReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	invite := &model.GroupInvite{
+		GroupId: createInviteReq.GroupId,
+		Role:    createInviteReq.Role,
+		MaxUses: createInviteReq.MaxUses,
+	}
+	err := h.ucase.GroupCreateInvite(principal.UserId, invite, time.Duration(createInviteReq.ExpireSeconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupInviteSuccess, invite)
+}
+
+func (h *groupHandler) GetInvites(e echo.Context) error {
+	getInvitesReq, ok := e.Get("body").(*model.GetInvitesReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	invites, err := h.ucase.GroupInvites(principal.UserId, getInvitesReq.GroupId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupGetInvitesSuccess, invites)
+}
+
+func (h *groupHandler) RevokeInvite(e echo.Context) error {
+	revokeInviteReq, ok := e.Get("body").(*model.RevokeInviteReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupRevokeInvite(principal.UserId, revokeInviteReq.Code)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupRevokeSuccess, nil)
+}
+
+func (h *groupHandler) JoinByCode(e echo.Context) error {
+	joinByCodeReq, ok := e.Get("body").(*model.JoinByCodeReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	groupToUser := &model.GroupToUser{
+		UserId:       principal.UserId,
+		UserNickname: joinByCodeReq.UserNickname,
+		UserDisturb:  joinByCodeReq.UserDisturb,
+	}
+	err := h.ucase.GroupJoinByCode(groupToUser, joinByCodeReq.Code)
+	if err != nil {
+		return err
+	}
+	joinGroupRes := &model.JoinGroupRes{
+		GroupId:      groupToUser.GroupId,
+		UserNickname: groupToUser.UserNickname,
+		UserDisturb:  groupToUser.UserDisturb,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupJoinSuccess, joinGroupRes)
+}
+
+func (h *groupHandler) SetJoinPolicy(e echo.Context) error {
+	setJoinPolicyReq, ok := e.Get("body").(*model.SetJoinPolicyReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupSetJoinPolicy(principal.UserId, setJoinPolicyReq.GroupId, setJoinPolicyReq.JoinPolicy)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupJoinPolicySuccess, nil)
+}
+
+func (h *groupHandler) GetJoinRequests(e echo.Context) error {
+	getJoinRequestsReq, ok := e.Get("body").(*model.GetJoinRequestsReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	page := &model.Page[*model.JoinRequest]{
+		CurrentPage: getJoinRequestsReq.CurrentPage,
+		PageSize:    getJoinRequestsReq.PageSize,
+	}
+	err := h.ucase.GroupRequests(principal.UserId, getJoinRequestsReq.GroupId, page)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupRequestsSuccess, page)
+}
+
+func (h *groupHandler) ApproveJoinRequest(e echo.Context) error {
+	return h.handleJoinRequest(e, true)
+}
+
+func (h *groupHandler) RejectJoinRequest(e echo.Context) error {
+	return h.handleJoinRequest(e, false)
+}
+
+func (h *groupHandler) handleJoinRequest(e echo.Context, approve bool) error {
+	handleJoinRequestReq, ok := e.Get("body").(*model.HandleJoinRequestReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupHandleRequest(principal.UserId, handleJoinRequestReq.RequestId, approve)
+	if err != nil {
+		return err
+	}
+	if approve {
+		return h.res.Success(e, http.StatusOK, constant.MsgGroupApproveSuccess, nil)
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupRejectSuccess, nil)
+}
+
+func (h *groupHandler) BanGroupUser(e echo.Context) error {
+	banGroupUserReq, ok := e.Get("body").(*model.BanGroupUserReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	groupBan := &model.GroupBan{
+		GroupId: banGroupUserReq.GroupId,
+		UserId:  banGroupUserReq.UserId,
+		Reason:  banGroupUserReq.Reason,
+	}
+	err := h.ucase.GroupBan(principal.UserId, groupBan, time.Duration(banGroupUserReq.ExpireSeconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupBanSuccess, groupBan)
+}
+
+func (h *groupHandler) UnbanGroupUser(e echo.Context) error {
+	unbanGroupUserReq, ok := e.Get("body").(*model.UnbanGroupUserReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupUnban(principal.UserId, unbanGroupUserReq.GroupId, unbanGroupUserReq.UserId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupUnbanSuccess, nil)
+}
+
+func (h *groupHandler) GetGroupBans(e echo.Context) error {
+	getGroupBansReq, ok := e.Get("body").(*model.GetGroupBansReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	page := &model.Page[*model.GroupBan]{
+		CurrentPage: getGroupBansReq.CurrentPage,
+		PageSize:    getGroupBansReq.PageSize,
+	}
+	err := h.ucase.GroupBans(principal.UserId, getGroupBansReq.GroupId, page)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupGetBansSuccess, page)
+}
+
+func (h *groupHandler) MuteGroupUser(e echo.Context) error {
+	muteGroupUserReq, ok := e.Get("body").(*model.MuteGroupUserReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupMute(principal.UserId, muteGroupUserReq.GroupId, muteGroupUserReq.UserId, time.Duration(muteGroupUserReq.MuteSeconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupMuteSuccess, nil)
+}
+
+func (h *groupHandler) GetGroupDetail(e echo.Context) error {
+	getGroupDetailReq, ok := e.Get("body").(*model.GetGroupDetailReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	groupToUser := &model.GroupToUser{
+		GroupId: getGroupDetailReq.GroupId,
+		UserId:  principal.UserId,
+	}
+	err := h.ucase.GroupUserDetail(groupToUser)
+	if err != nil {
+		return err
+	}
+	getGroupDetailRes := &model.GetGroupDetailRes{
+		GroupId:          groupToUser.GroupId,
+		GroupName:        groupToUser.GroupName,
+		GroupNickname:    groupToUser.GroupNickname,
+		GroupAvatar:      groupToUser.GroupAvatar,
+		GroupMaxSize:     groupToUser.GroupMaxSize,
+		GroupCurrentSize: groupToUser.GroupCurrentSize,
+		UserNickname:     groupToUser.UserNickname,
+		UserRole:         groupToUser.UserRole,
+		UserDisturb:      groupToUser.UserDisturb,
+		MuteUntil:        groupToUser.MuteUntil,
+		Announcement:     groupToUser.Announcement,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupGetDetailSuccess, getGroupDetailRes)
+}
+
+func (h *groupHandler) CreateAnnouncement(e echo.Context) error {
+	createAnnouncementReq, ok := e.Get("body").(*model.CreateAnnouncementReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	announcement := &model.GroupAnnouncement{
+		GroupId: createAnnouncementReq.GroupId,
+		Title:   createAnnouncementReq.Title,
+		Content: createAnnouncementReq.Content,
+		Pinned:  createAnnouncementReq.Pinned,
+		NeedAck: createAnnouncementReq.NeedAck,
+	}
+	err := h.ucase.GroupAnnounce(principal.UserId, announcement)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupAnnounceSuccess, announcement)
+}
+
+func (h *groupHandler) UpdateAnnouncement(e echo.Context) error {
+	updateAnnouncementReq, ok := e.Get("body").(*model.UpdateAnnouncementReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	announcement := &model.GroupAnnouncement{
+		AnnouncementId: updateAnnouncementReq.AnnouncementId,
+		Title:          updateAnnouncementReq.Title,
+		Content:        updateAnnouncementReq.Content,
+		Pinned:         updateAnnouncementReq.Pinned,
+		NeedAck:        updateAnnouncementReq.NeedAck,
+	}
+	err := h.ucase.GroupUpdateAnnouncement(principal.UserId, announcement)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupAnnounceUpdated, announcement)
+}
+
+func (h *groupHandler) DeleteAnnouncement(e echo.Context) error {
+	announcementReq, ok := e.Get("body").(*model.AnnouncementReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupDeleteAnnouncement(principal.UserId, announcementReq.AnnouncementId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupAnnounceDeleted, nil)
+}
+
+func (h *groupHandler) GetAnnouncements(e echo.Context) error {
+	getAnnouncementsReq, ok := e.Get("body").(*model.GetAnnouncementsReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	page := &model.Page[*model.GroupAnnouncement]{
+		CurrentPage: getAnnouncementsReq.CurrentPage,
+		PageSize:    getAnnouncementsReq.PageSize,
+	}
+	err := h.ucase.GroupAnnouncements(principal.UserId, getAnnouncementsReq.GroupId, page)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupAnnouncesSuccess, page)
+}
+
+func (h *groupHandler) AckAnnouncement(e echo.Context) error {
+	announcementReq, ok := e.Get("body").(*model.AnnouncementReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.GroupAckAnnouncement(principal.UserId, announcementReq.AnnouncementId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgGroupAnnounceAcked, nil)
+}
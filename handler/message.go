@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/usecase"
+)
+
+type MessageHandler interface {
+	SendSingle(e echo.Context) error
+	SendGroup(e echo.Context) error
+	Sync(e echo.Context) error
+	MarkRead(e echo.Context) error
+	Recall(e echo.Context) error
+}
+
+type messageHandler struct {
+	ucase  usecase.MessageUsecase
+	logger log.Logger
+	res    model.Response
+}
+
+func NewMessageHandler(ucase usecase.MessageUsecase, res model.Response) MessageHandler {
+	return &messageHandler{
+		ucase:  ucase,
+		logger: ucase.GetLogger(),
+		res:    res,
+	}
+}
+
+func (h *messageHandler) SendSingle(e echo.Context) error {
+	sendSingleReq, ok := e.Get("body").(*model.SendSingleReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	message := &model.Message{
+		Sender:   principal.UserId,
+		Receiver: sendSingleReq.ReceiverId,
+		Type:     sendSingleReq.Type,
+		Text:     sendSingleReq.Text,
+		Content:  sendSingleReq.Payload,
+	}
+	sequenceId, err := h.ucase.SendSingle(message)
+	if err != nil {
+		return err
+	}
+	sendSingleRes := &model.SendSingleRes{
+		MessageId:  message.MessageId,
+		SequenceId: sequenceId,
+		SendTime:   message.SendTime,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgMessageSendSuccess, sendSingleRes)
+}
+
+func (h *messageHandler) SendGroup(e echo.Context) error {
+	sendGroupReq, ok := e.Get("body").(*model.SendGroupReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	message := &model.Message{
+		Sender:   principal.UserId,
+		Receiver: sendGroupReq.GroupId,
+		Type:     sendGroupReq.Type,
+		Text:     sendGroupReq.Text,
+		Content:  sendGroupReq.Payload,
+	}
+	timeline, err := h.ucase.SendGroup(message)
+	if err != nil {
+		return err
+	}
+	sendGroupRes := &model.SendGroupRes{
+		MessageId:    message.MessageId,
+		TimelineType: timeline.TimelineType,
+		TimelineId:   timeline.TimelineId,
+		SequenceId:   timeline.SequenceId,
+		SendTime:     message.SendTime,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgMessageSendSuccess, sendGroupRes)
+}
+
+func (h *messageHandler) Sync(e echo.Context) error {
+	syncReq, ok := e.Get("body").(*model.SyncReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	timelines, err := h.ucase.Sync(principal.UserId, syncReq.Cursors, syncReq.PageSize)
+	if err != nil {
+		return err
+	}
+	syncRes := &model.SyncRes{
+		Timelines: timelines,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgMessageSyncSuccess, syncRes)
+}
+
+func (h *messageHandler) MarkRead(e echo.Context) error {
+	markReadReq, ok := e.Get("body").(*model.MarkReadReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	readReceipt := &model.ReadReceipt{
+		ReaderId:     principal.UserId,
+		DialogType:   markReadReq.DialogType,
+		PeerId:       markReadReq.PeerId,
+		TimelineType: markReadReq.TimelineType,
+		TimelineId:   markReadReq.TimelineId,
+		SequenceId:   markReadReq.SequenceId,
+	}
+	err := h.ucase.MarkRead(readReceipt)
+	if err != nil {
+		return err
+	}
+	markReadRes := &model.MarkReadRes{
+		DialogType: readReceipt.DialogType,
+		PeerId:     readReceipt.PeerId,
+		SequenceId: readReceipt.SequenceId,
+		MessageIds: readReceipt.MessageIds,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgMessageReadSuccess, markReadRes)
+}
+
+func (h *messageHandler) Recall(e echo.Context) error {
+	recallReq, ok := e.Get("body").(*model.RecallReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	recallNotice := &model.RecallNotice{
+		MessageId:  recallReq.MessageId,
+		OperatorId: principal.UserId,
+	}
+	err := h.ucase.Recall(recallNotice)
+	if err != nil {
+		return err
+	}
+	recallRes := &model.RecallRes{
+		MessageId:  recallNotice.MessageId,
+		RecallTime: recallNotice.RecallTime,
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgMessageRecallSuccess, recallRes)
+}
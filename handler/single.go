@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
 	"github.com/wendisx/gorchat/model"
@@ -17,6 +18,11 @@ type SingleHandler interface {
 	UpdateDisturb(e echo.Context) error
 	GetDetail(e echo.Context) error
 	Delete(e echo.Context) error
+	GetList(e echo.Context) error
+	GetIncoming(e echo.Context) error
+	GetOutgoing(e echo.Context) error
+	Decline(e echo.Context) error
+	Cancel(e echo.Context) error
 }
 
 type singleHandler struct {
@@ -38,8 +44,12 @@ func (h *singleHandler) Invite(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	singleInvite := &model.SingleInvite{
-		InviterId:       inviteReq.InviterId,
+		InviterId:       principal.UserId,
 		InviteeId:       inviteReq.InviteeId,
 		InviteeNickname: inviteReq.InviteeNickname,
 		InviterDisturb:  inviteReq.InviterDisturb,
@@ -63,9 +73,13 @@ func (h *singleHandler) Accept(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	singleAccept := &model.SingleAccept{
 		SingleId:        acceptReq.SingleId,
-		InviteeId:       acceptReq.InviteeId,
+		InviteeId:       principal.UserId,
 		InviterNickname: acceptReq.InviterNickname,
 		InviteeDisturb:  acceptReq.InviteeDisturb,
 	}
@@ -88,11 +102,15 @@ func (h *singleHandler) UpdateNickname(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	var err error
 	if updateNicknameReq.IsInviter {
 		inviter := &model.SingleInviter{
 			SingleId:        updateNicknameReq.SingleId,
-			InviterId:       updateNicknameReq.UserId,
+			InviterId:       principal.UserId,
 			InviteeNickname: updateNicknameReq.SetNickname,
 			InviterDisturb:  updateNicknameReq.UserDisturb,
 		}
@@ -110,7 +128,7 @@ func (h *singleHandler) UpdateNickname(e echo.Context) error {
 	}
 	invitee := &model.SingleInvitee{
 		SingleId:        updateNicknameReq.SingleId,
-		InviteeId:       updateNicknameReq.UserId,
+		InviteeId:       principal.UserId,
 		InviterNickname: updateNicknameReq.SetNickname,
 		InviteeDisturb:  updateNicknameReq.UserDisturb,
 	}
@@ -132,11 +150,15 @@ func (h *singleHandler) UpdateDisturb(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	var err error
 	if updateDisturbReq.IsInviter {
 		inviter := &model.SingleInviter{
 			SingleId:        updateDisturbReq.SingleId,
-			InviterId:       updateDisturbReq.UserId,
+			InviterId:       principal.UserId,
 			InviterDisturb:  updateDisturbReq.SetDisturb,
 			InviteeNickname: updateDisturbReq.UserNickname,
 		}
@@ -152,7 +174,7 @@ func (h *singleHandler) UpdateDisturb(e echo.Context) error {
 	}
 	invitee := &model.SingleInvitee{
 		SingleId:        updateDisturbReq.SingleId,
-		InviteeId:       updateDisturbReq.UserId,
+		InviteeId:       principal.UserId,
 		InviteeDisturb:  updateDisturbReq.SetDisturb,
 		InviterNickname: updateDisturbReq.UserNickname,
 	}
@@ -173,11 +195,15 @@ func (h *singleHandler) GetDetail(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	var err error
 	if getDetailReq.IsInviter {
 		inviter := &model.SingleInviter{
 			SingleId:  getDetailReq.SingleId,
-			InviterId: getDetailReq.UserId,
+			InviterId: principal.UserId,
 		}
 		err = h.ucase.GetDetailForInviter(inviter)
 		if err != nil {
@@ -194,7 +220,7 @@ func (h *singleHandler) GetDetail(e echo.Context) error {
 	}
 	invitee := &model.SingleInvitee{
 		SingleId:  getDetailReq.SingleId,
-		InviteeId: getDetailReq.UserId,
+		InviteeId: principal.UserId,
 	}
 	err = h.ucase.GetDetailForInvitee(invitee)
 	if err != nil {
@@ -215,14 +241,102 @@ func (h *singleHandler) Delete(e echo.Context) error {
 	if !ok {
 		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
 	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	singleDelete := &model.SingleDelete{
 		SingleId:  deleteReq.SingleId,
-		InviterId: deleteReq.InviterId,
-		InviteeId: deleteReq.InviteeId,
+		InviterId: deleteReq.PeerId,
+		InviteeId: principal.UserId,
 	}
-	err := h.ucase.Delete(singleDelete)
+	if deleteReq.IsInviter {
+		singleDelete.InviterId = principal.UserId
+		singleDelete.InviteeId = deleteReq.PeerId
+	}
+	err := h.ucase.Delete(principal.UserId, singleDelete)
 	if err != nil {
 		return err
 	}
 	return h.res.Success(e, http.StatusOK, constant.MsgSingleDeleteSuccess, nil)
 }
+
+func (h *singleHandler) GetList(e echo.Context) error {
+	getContactsReq, ok := e.Get("body").(*model.GetContactsReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	page := &model.Page[*model.Contact]{
+		CurrentPage: getContactsReq.CurrentPage,
+		PageSize:    getContactsReq.PageSize,
+	}
+	err := h.ucase.Contacts(principal.UserId, getContactsReq.TagId, page)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgSingleListSuccess, page)
+}
+
+func (h *singleHandler) GetIncoming(e echo.Context) error {
+	return h.getRequests(e, true)
+}
+
+func (h *singleHandler) GetOutgoing(e echo.Context) error {
+	return h.getRequests(e, false)
+}
+
+func (h *singleHandler) getRequests(e echo.Context, incoming bool) error {
+	getRequestsReq, ok := e.Get("body").(*model.GetRequestsReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	page := &model.Page[*model.SingleRequest]{
+		CurrentPage: getRequestsReq.CurrentPage,
+		PageSize:    getRequestsReq.PageSize,
+	}
+	err := h.ucase.Requests(principal.UserId, incoming, page)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgSingleRequestsSuccess, page)
+}
+
+func (h *singleHandler) Decline(e echo.Context) error {
+	requestReq, ok := e.Get("body").(*model.RequestReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.Decline(principal.UserId, requestReq.SingleId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgSingleDeclineSuccess, nil)
+}
+
+func (h *singleHandler) Cancel(e echo.Context) error {
+	requestReq, ok := e.Get("body").(*model.RequestReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.Cancel(principal.UserId, requestReq.SingleId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgSingleCancelSuccess, nil)
+}
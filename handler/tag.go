@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/usecase"
+)
+
+type TagHandler interface {
+	CreateTag(e echo.Context) error
+	UpdateTag(e echo.Context) error
+	DeleteTag(e echo.Context) error
+	GetTags(e echo.Context) error
+	AddItem(e echo.Context) error
+	RemoveItem(e echo.Context) error
+}
+
+type tagHandler struct {
+	ucase  usecase.TagUsecase
+	logger log.Logger
+	res    model.Response
+}
+
+func NewTagHandler(ucase usecase.TagUsecase, res model.Response) TagHandler {
+	return &tagHandler{
+		ucase:  ucase,
+		logger: ucase.GetLogger(),
+		res:    res,
+	}
+}
+
+func (h *tagHandler) CreateTag(e echo.Context) error {
+	createTagReq, ok := e.Get("body").(*model.CreateTagReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	tag, err := h.ucase.CreateTag(principal.UserId, createTagReq.TagName)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgTagCreateSuccess, tag)
+}
+
+func (h *tagHandler) UpdateTag(e echo.Context) error {
+	updateTagReq, ok := e.Get("body").(*model.UpdateTagReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.UpdateTag(principal.UserId, updateTagReq.TagId, updateTagReq.TagName)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgTagUpdateSuccess, nil)
+}
+
+func (h *tagHandler) DeleteTag(e echo.Context) error {
+	tagReq, ok := e.Get("body").(*model.TagReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.DeleteTag(principal.UserId, tagReq.TagId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgTagDeleteSuccess, nil)
+}
+
+func (h *tagHandler) GetTags(e echo.Context) error {
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	tags, err := h.ucase.Tags(principal.UserId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgTagListSuccess, tags)
+}
+
+func (h *tagHandler) AddItem(e echo.Context) error {
+	tagItemReq, ok := e.Get("body").(*model.TagItemReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.AddItem(principal.UserId, &model.TagItem{
+		TagId:      tagItemReq.TagId,
+		DialogType: tagItemReq.DialogType,
+		DialogId:   tagItemReq.DialogId,
+	})
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgTagAddItemSuccess, nil)
+}
+
+func (h *tagHandler) RemoveItem(e echo.Context) error {
+	tagItemReq, ok := e.Get("body").(*model.TagItemReq)
+	if !ok {
+		return h.res.Fail(e, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.RemoveItem(principal.UserId, &model.TagItem{
+		TagId:      tagItemReq.TagId,
+		DialogType: tagItemReq.DialogType,
+		DialogId:   tagItemReq.DialogId,
+	})
+	if err != nil {
+		return err
+	}
+	return h.res.Success(e, http.StatusOK, constant.MsgTagRemoveItemSuccess, nil)
+}
@@ -2,10 +2,11 @@ package handler
 
 import (
 	"net/http"
-	"regexp"
-	"strconv"
+	"time"
 
+	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
 	"github.com/wendisx/gorchat/model"
@@ -19,19 +20,24 @@ type UserHandler interface {
 	Delete(c echo.Context) error
 	GetUserdetail(c echo.Context) error
 	SearchUser(c echo.Context) error
+	Block(c echo.Context) error
+	Unblock(c echo.Context) error
+	GetBlocks(c echo.Context) error
 }
 
 type userHandler struct {
 	ucase  usecase.UserUsecase
 	logger log.Logger
 	res    model.Response
+	store  sessions.Store
 }
 
-func NewUserHandler(ucase usecase.UserUsecase, res model.Response) UserHandler {
+func NewUserHandler(ucase usecase.UserUsecase, res model.Response, store sessions.Store) UserHandler {
 	return &userHandler{
 		ucase:  ucase,
 		logger: ucase.GetLogger(),
 		res:    res,
+		store:  store,
 	}
 }
 
@@ -62,6 +68,14 @@ func (h *userHandler) Login(c echo.Context) error {
 			return err
 		}
 	}
+	err = auth.Bind(h.store, c, &auth.Principal{
+		UserId:    user.UserId,
+		LoginTime: time.Now(),
+		Device:    auth.Device(c.Request(), loginReq.Device),
+	})
+	if err != nil {
+		return h.res.Fail(c, http.StatusInternalServerError, int(constant.ErrLoginFail), constant.MsgServerInternalErr)
+	}
 	loginRes := model.LoginRes{
 		UserId:       user.UserId,
 		UserName:     user.UserName,
@@ -78,8 +92,12 @@ func (h *userHandler) Login(c echo.Context) error {
 
 func (h *userHandler) UpdateInfo(c echo.Context) error {
 	updateInfoReq := c.Get("body").(*model.UpdateInfoReq)
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	updateUser := &model.User{
-		UserId:       updateInfoReq.UserId,
+		UserId:       principal.UserId,
 		UserName:     updateInfoReq.UserName,
 		UserEmail:    updateInfoReq.UserEmail,
 		UserPhone:    updateInfoReq.UserPhone,
@@ -89,7 +107,7 @@ func (h *userHandler) UpdateInfo(c echo.Context) error {
 		UserLocation: updateInfoReq.UserLocation,
 		UserAvatar:   updateInfoReq.UserAvatar,
 	}
-	user, err := h.ucase.UpdateInfo(updateUser)
+	user, err := h.ucase.UpdateInfo(principal.UserId, updateUser)
 	if err != nil || user == nil {
 		if derr, ok := err.(*model.DError); ok {
 			return h.res.Fail(c, http.StatusInternalServerError, int(derr.Code), derr.Message)
@@ -109,31 +127,27 @@ func (h *userHandler) UpdateInfo(c echo.Context) error {
 }
 
 func (h *userHandler) Delete(c echo.Context) error {
-	userIdRegex := regexp.MustCompile(`^\d+$`)
-	userIdStr := c.QueryParam("userId")
-	ok := userIdRegex.MatchString(userIdStr)
-	userId, err := strconv.Atoi(userIdStr)
-	if userIdStr == "" || !ok || err != nil {
-		return h.res.Fail(c, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
 	}
-	err = h.ucase.Delete(int64(userId))
+	err := h.ucase.Delete(principal.UserId, principal.UserId)
 	if err != nil {
 		if derr, ok := err.(*model.DError); ok {
 			return h.res.Fail(c, http.StatusInternalServerError, int(derr.Code), derr.Message)
 		}
 	}
+	// 账号注销后当前会话随之失效
+	auth.Unbind(h.store, c)
 	return h.res.Success(c, http.StatusOK, constant.MsgUserDeleteSuccess, nil)
 }
 
 func (h *userHandler) GetUserdetail(c echo.Context) error {
-	userIdRegex := regexp.MustCompile(`^\d+$`)
-	userIdStr := c.QueryParam("userId")
-	ok := userIdRegex.MatchString(userIdStr)
-	userId, err := strconv.Atoi(userIdStr)
-	if userIdStr == "" || !ok || err != nil {
-		return h.res.Fail(c, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
 	}
-	user, err := h.ucase.GetUserDetail(int64(userId))
+	user, err := h.ucase.GetUserDetail(principal.UserId)
 	if err != nil {
 		return err
 	}
@@ -153,6 +167,10 @@ func (h *userHandler) GetUserdetail(c echo.Context) error {
 
 func (h *userHandler) SearchUser(c echo.Context) error {
 	searchUserReq := c.Get("body").(*model.SearchUserReq)
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
 	var userBasic model.UserBasic
 	userBasic.UserName = searchUserReq.UserName
 	userBasic.UserId = searchUserReq.UserId
@@ -160,7 +178,7 @@ func (h *userHandler) SearchUser(c echo.Context) error {
 		CurrentPage: searchUserReq.CurrentPage,
 		PageSize:    searchUserReq.PageSize,
 	}
-	err := h.ucase.SearchUsers(userBasic.UserId, userBasic.UserName, page)
+	err := h.ucase.SearchUsers(principal.UserId, userBasic.UserId, userBasic.UserName, page)
 	if err != nil {
 		return err
 	}
@@ -172,3 +190,55 @@ func (h *userHandler) SearchUser(c echo.Context) error {
 	}
 	return h.res.Success(c, http.StatusOK, constant.MsgSearchUserSuccess, searchUserRes)
 }
+
+func (h *userHandler) Block(c echo.Context) error {
+	blockUserReq, ok := c.Get("body").(*model.BlockUserReq)
+	if !ok {
+		return h.res.Fail(c, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.Block(principal.UserId, blockUserReq.UserId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(c, http.StatusOK, constant.MsgUserBlockSuccess, nil)
+}
+
+func (h *userHandler) Unblock(c echo.Context) error {
+	blockUserReq, ok := c.Get("body").(*model.BlockUserReq)
+	if !ok {
+		return h.res.Fail(c, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	err := h.ucase.Unblock(principal.UserId, blockUserReq.UserId)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(c, http.StatusOK, constant.MsgUserUnblockSuccess, nil)
+}
+
+func (h *userHandler) GetBlocks(c echo.Context) error {
+	getBlocksReq, ok := c.Get("body").(*model.GetBlocksReq)
+	if !ok {
+		return h.res.Fail(c, http.StatusBadRequest, int(constant.ErrBadRequest), constant.MsgBadRequest)
+	}
+	principal, ok := auth.GetPrincipal(c)
+	if !ok {
+		return h.res.Fail(c, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	page := &model.Page[*model.UserBlock]{
+		CurrentPage: getBlocksReq.CurrentPage,
+		PageSize:    getBlocksReq.PageSize,
+	}
+	err := h.ucase.Blocks(principal.UserId, page)
+	if err != nil {
+		return err
+	}
+	return h.res.Success(c, http.StatusOK, constant.MsgUserGetBlocksSuccess, page)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/auth"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/internal/ws"
+	"github.com/wendisx/gorchat/model"
+)
+
+type WsHandler interface {
+	Connect(e echo.Context) error
+}
+
+type wsHandler struct {
+	hub      ws.Hub
+	upgrader websocket.Upgrader
+	logger   log.Logger
+	res      model.Response
+}
+
+func NewWsHandler(hub ws.Hub, logger log.Logger, res model.Response) WsHandler {
+	return &wsHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+		logger: logger,
+		res:    res,
+	}
+}
+
+func (h *wsHandler) Connect(e echo.Context) error {
+	principal, ok := auth.GetPrincipal(e)
+	if !ok {
+		return h.res.Fail(e, http.StatusUnauthorized, int(constant.ErrNotAuthenticate), constant.MsgNotAuthenticate)
+	}
+	conn, err := h.upgrader.Upgrade(e.Response(), e.Request(), nil)
+	if err != nil {
+		// 升级失败时 upgrader 已经写回了错误响应
+		log.Error(
+			h.logger,
+			"websocket upgrade",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil
+	}
+	client := ws.NewClient(h.hub, conn, principal.UserId)
+	client.Serve()
+	return nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/wendisx/gorchat/internal/constant"
+)
+
+// 当前登录用户，由登录时写入 session 的信息还原
+type Principal struct {
+	UserId    int64
+	LoginTime time.Time
+	Device    string
+}
+
+// 登录成功后将身份写入 session，同时更换 session id 防止会话固定
+func Bind(store sessions.Store, c echo.Context, principal *Principal) error {
+	session, err := store.Get(c.Request(), constant.SESSION_KEY)
+	if err != nil && session == nil {
+		return err
+	}
+	session.ID = ""
+	session.Values[constant.SESSION_USER_ID] = principal.UserId
+	session.Values[constant.SESSION_LOGIN_TIME] = principal.LoginTime.Unix()
+	session.Values[constant.SESSION_DEVICE] = principal.Device
+	return store.Save(c.Request(), c.Response().Writer, session)
+}
+
+// 注销时清除 session
+func Unbind(store sessions.Store, c echo.Context) error {
+	session, err := store.Get(c.Request(), constant.SESSION_KEY)
+	if err != nil && session == nil {
+		return err
+	}
+	session.Options.MaxAge = -1
+	return store.Save(c.Request(), c.Response().Writer, session)
+}
+
+// 从 session 还原登录身份，未登录的 session 返回 false
+func FromSession(session *sessions.Session) (*Principal, bool) {
+	userId, ok := session.Values[constant.SESSION_USER_ID].(int64)
+	if !ok || userId == 0 {
+		return nil, false
+	}
+	loginTime, _ := session.Values[constant.SESSION_LOGIN_TIME].(int64)
+	device, _ := session.Values[constant.SESSION_DEVICE].(string)
+	return &Principal{
+		UserId:    userId,
+		LoginTime: time.Unix(loginTime, 0),
+		Device:    device,
+	}, true
+}
+
+func SetPrincipal(c echo.Context, principal *Principal) {
+	c.Set(constant.PRINCIPAL_KEY, principal)
+}
+
+func GetPrincipal(c echo.Context) (*Principal, bool) {
+	principal, ok := c.Get(constant.PRINCIPAL_KEY).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// 客户端未声明设备时以 User-Agent 作为设备标识
+func Device(r *http.Request, device string) string {
+	if device != "" {
+		return device
+	}
+	return r.UserAgent()
+}
@@ -48,6 +48,48 @@ const (
 	ErrGroupSearchUserFail  // 搜索用户失败
 	ErrGroupSearchFail      // 群搜索失败
 	ErrGroupGetAllUsersFail // 群获取用户失败
+
+	ErrMessageSendFail   // 消息发送失败
+	ErrMessageNotContact // 非单聊联系人
+	ErrMessageNotMember  // 非群成员
+	ErrMessageSyncFail   // 消息同步失败
+	ErrMessageReadFail   // 消息已读失败
+	ErrMessageNotFound   // 消息不存在
+	ErrMessageRecallFail // 消息撤回失败
+	ErrMessageRecallExp  // 消息超过撤回时限
+	ErrMessageContent    // 消息内容格式错误
+
+	ErrFileUploadFail // 文件上传失败
+	ErrFileTooLarge   // 文件过大
+	ErrFileNotFound   // 文件不存在
+	ErrUploadInitFail // 上传任务创建失败
+	ErrUploadNotFound // 上传任务不存在
+	ErrUploadPartFail // 分片上传失败
+	ErrUploadNotDone  // 分片未全部上传
+
+	ErrPermissionDenied // 没有操作权限
+
+	ErrGroupPasswordFail    // 群密码错误
+	ErrGroupFull            // 群人数已满
+	ErrGroupAlreadyMember   // 已经是群成员
+	ErrGroupTransferFail    // 群主转让失败
+	ErrGroupInviteFail      // 邀请码创建失败
+	ErrGroupInviteInvalid   // 邀请码无效
+	ErrGroupJoinPolicy      // 不支持的入群方式
+	ErrGroupRequestFail     // 入群申请失败
+	ErrGroupRequestDone     // 入群申请不存在或已处理
+	ErrGroupBanned          // 已被群封禁
+	ErrGroupMuted           // 群内禁言中
+	ErrGroupAnnounceFail    // 群公告操作失败
+	ErrSingleRequestPending // 单聊申请待处理
+	ErrSingleAlreadyFriend  // 已建立单聊
+	ErrSingleRequestDone    // 单聊申请不存在或已处理
+	ErrUserBlocked          // 已被对方屏蔽
+	ErrUserBlockFail        // 屏蔽操作失败
+	ErrTagExist             // 标签已存在
+	ErrTagNotExist          // 标签不存在
+	ErrTagFail              // 标签操作失败
+	ErrConversationFail     // 会话操作失败
 )
 
 // 错误信息
@@ -96,6 +138,48 @@ const (
 	MsgGroupSearchUserFail  = "搜索用户失败"
 	MsgGroupSearchFail      = "群搜索失败"
 	MsgGroupGetAllUsersFail = "群用户返回失败"
+
+	MsgMessageSendFail   = "消息发送失败"
+	MsgMessageNotContact = "对方不是你的联系人"
+	MsgMessageNotMember  = "你不是该群成员"
+	MsgMessageSyncFail   = "消息同步失败"
+	MsgMessageReadFail   = "消息已读失败"
+	MsgMessageNotFound   = "消息不存在"
+	MsgMessageRecallFail = "消息撤回失败"
+	MsgMessageRecallExp  = "消息已超过可撤回时间"
+	MsgMessageContent    = "消息内容格式错误"
+
+	MsgFileUploadFail = "文件上传失败"
+	MsgFileTooLarge   = "文件超过大小限制"
+	MsgFileNotFound   = "文件不存在"
+	MsgUploadInitFail = "上传任务创建失败"
+	MsgUploadNotFound = "上传任务不存在或已过期"
+	MsgUploadPartFail = "分片上传失败"
+	MsgUploadNotDone  = "分片未全部上传"
+
+	MsgPermissionDenied = "没有操作权限"
+
+	MsgGroupPasswordFail    = "群密码错误"
+	MsgGroupFull            = "群人数已满"
+	MsgGroupAlreadyMember   = "你已经是该群成员"
+	MsgGroupTransferFail    = "群主转让失败"
+	MsgGroupInviteFail      = "邀请码创建失败"
+	MsgGroupInviteInvalid   = "邀请码无效或已过期"
+	MsgGroupJoinPolicy      = "该群不支持此方式加入"
+	MsgGroupRequestFail     = "入群申请失败"
+	MsgGroupRequestDone     = "入群申请不存在或已处理"
+	MsgGroupBanned          = "你已被该群封禁"
+	MsgGroupMuted           = "你在该群中已被禁言"
+	MsgGroupAnnounceFail    = "群公告操作失败"
+	MsgSingleRequestPending = "已经发送过申请，等待对方处理"
+	MsgSingleAlreadyFriend  = "你们已经建立了单聊"
+	MsgSingleRequestDone    = "申请不存在或已处理"
+	MsgUserBlocked          = "你已被对方屏蔽"
+	MsgUserBlockFail        = "屏蔽操作失败"
+	MsgTagExist             = "标签已存在"
+	MsgTagNotExist          = "标签不存在"
+	MsgTagFail              = "标签操作失败"
+	MsgConversationFail     = "会话操作失败"
 )
 
 // 一般提示信息
@@ -108,12 +192,29 @@ const (
 	MsgUserDeleteSuccess    = "用户删除成功"
 	MsgGetUserDetailSuccess = "获取用户详细信息成功"
 	MsgSearchUserSuccess    = "搜索用户成功"
+	MsgUserBlockSuccess     = "屏蔽用户成功"
+	MsgUserUnblockSuccess   = "取消屏蔽成功"
+	MsgUserGetBlocksSuccess = "屏蔽列表获取成功"
 
 	MsgSingleInviteSuccess    = "单聊邀请成功"
 	MsgSingleAcceptSuccess    = "单聊接受成功"
 	MsgSingleUpdateSuccess    = "单聊更新成功"
 	MsgSingleGetDetailSuccess = "单聊详细信息获取成功"
 	MsgSingleDeleteSuccess    = "单聊删除成功"
+	MsgSingleRequestsSuccess  = "单聊申请获取成功"
+	MsgSingleDeclineSuccess   = "已拒绝单聊申请"
+	MsgSingleCancelSuccess    = "已取消单聊申请"
+	MsgSingleListSuccess      = "单聊列表获取成功"
+
+	MsgTagCreateSuccess     = "标签创建成功"
+	MsgTagUpdateSuccess     = "标签更新成功"
+	MsgTagDeleteSuccess     = "标签删除成功"
+	MsgTagListSuccess       = "标签列表获取成功"
+	MsgTagAddItemSuccess    = "标签关联成功"
+	MsgTagRemoveItemSuccess = "标签取消关联成功"
+
+	MsgConversationListSuccess = "会话列表获取成功"
+	MsgConversationPinSuccess  = "会话置顶设置成功"
 
 	MsgGroupCreateSuccess      = "群聊创建成功"
 	MsgGroupJoinSuccess        = "群聊加入成功"
@@ -125,4 +226,33 @@ const (
 	MsgGroupSearchUserSuccess  = "搜索用户成功"
 	MsgGroupSearchSuccess      = "群搜索成功"
 	MsgGroupGetAllUsersSuccess = "群用户返回成功"
+	MsgGroupPermissionSuccess  = "群权限获取成功"
+	MsgGroupTransferSuccess    = "群主转让成功"
+	MsgGroupInviteSuccess      = "邀请码创建成功"
+	MsgGroupGetInvitesSuccess  = "邀请码获取成功"
+	MsgGroupRevokeSuccess      = "邀请码撤销成功"
+	MsgGroupApplySuccess       = "入群申请已提交"
+	MsgGroupRequestsSuccess    = "入群申请获取成功"
+	MsgGroupApproveSuccess     = "已同意入群申请"
+	MsgGroupRejectSuccess      = "已拒绝入群申请"
+	MsgGroupJoinPolicySuccess  = "入群方式设置成功"
+	MsgGroupBanSuccess         = "封禁成功"
+	MsgGroupUnbanSuccess       = "解除封禁成功"
+	MsgGroupGetBansSuccess     = "封禁列表获取成功"
+	MsgGroupMuteSuccess        = "禁言设置成功"
+	MsgGroupAnnounceSuccess    = "群公告发布成功"
+	MsgGroupAnnounceUpdated    = "群公告修改成功"
+	MsgGroupAnnounceDeleted    = "群公告删除成功"
+	MsgGroupAnnouncesSuccess   = "群公告获取成功"
+	MsgGroupAnnounceAcked      = "群公告已确认"
+
+	MsgMessageSendSuccess   = "消息发送成功"
+	MsgMessageSyncSuccess   = "消息同步成功"
+	MsgMessageReadSuccess   = "消息已读成功"
+	MsgMessageRecallSuccess = "消息撤回成功"
+
+	MsgFileUploadSuccess = "文件上传成功"
+	MsgUploadInitSuccess = "上传任务创建成功"
+	MsgUploadPartSuccess = "分片上传成功"
+	MsgUploadGetSuccess  = "上传进度获取成功"
 )
@@ -1,4 +1,12 @@
 package constant
 
 // 中间件常量配置
-const ()
+const (
+	// 登录后写入 session 的身份信息
+	SESSION_USER_ID    = "user_id"
+	SESSION_LOGIN_TIME = "login_time"
+	SESSION_DEVICE     = "device"
+
+	// echo.Context 中保存当前登录用户的 key
+	PRINCIPAL_KEY = "principal"
+)
@@ -8,6 +8,15 @@ const (
 	PROD_ENV_FILE       = ".prod.env"
 
 	SESSION_KEY = "Identifier"
+
+	DEFAULT_MESSAGE_RECALL_WINDOW = "2m"
+	DEFAULT_BLOB_ROOT             = "../.blob"
+	DEFAULT_BLOB_MAX_SIZE         = "1073741824"
+	DEFAULT_UPLOAD_MAX_SIZE       = "20971520"
+	DEFAULT_UPLOAD_TTL            = "24h"
+	DEFAULT_UPLOAD_GC_INTERVAL    = "1h"
+	DEFAULT_SINGLE_REQUEST_TTL    = "168h"
+	DEFAULT_SINGLE_GC_INTERVAL    = "1h"
 )
 
 // 环境变量
@@ -25,4 +34,16 @@ const (
 	REDIS_USERNAME = "REDIS_USERNAME"
 	REDIS_PASSWORD = "REDIS_PASSWORD"
 	REDIS_DATABASE = "REDIS_DATABASE"
+
+	MESSAGE_RECALL_WINDOW = "MESSAGE_RECALL_WINDOW"
+
+	BLOB_ROOT     = "BLOB_ROOT"
+	BLOB_MAX_SIZE = "BLOB_MAX_SIZE"
+
+	UPLOAD_MAX_SIZE    = "UPLOAD_MAX_SIZE"
+	UPLOAD_TTL         = "UPLOAD_TTL"
+	UPLOAD_GC_INTERVAL = "UPLOAD_GC_INTERVAL"
+
+	SINGLE_REQUEST_TTL = "SINGLE_REQUEST_TTL"
+	SINGLE_GC_INTERVAL = "SINGLE_GC_INTERVAL"
 )
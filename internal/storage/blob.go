@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+)
+
+const (
+	BLOB_ID_FORMAT = `^[0-9a-f]{64}$`
+	SNIFF_SIZE     = 512
+)
+
+var (
+	ErrBlobTooLarge = errors.New("blob too large")
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrBlobInvalid  = errors.New("blob id invalid")
+)
+
+var blobIdRegex = regexp.MustCompile(BLOB_ID_FORMAT)
+
+// 按内容寻址的二进制对象，id 为内容的 sha256 十六进制
+type Blob struct {
+	Id       string `json:"blobId"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// 二进制对象存储，相同内容只保存一份
+type BlobStore interface {
+	Put(ctx context.Context, r io.Reader) (*Blob, error)
+	Open(ctx context.Context, id string) (io.ReadSeekCloser, *Blob, error)
+	Exists(ctx context.Context, id string) (bool, error)
+}
+
+func ValidId(id string) bool {
+	return blobIdRegex.MatchString(id)
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const (
+	TMP_DIR = "tmp"
+)
+
+// 本地磁盘存储: root/ab/cd/<id>，上传先写入 root/tmp 再原子重命名
+type localStore struct {
+	root    string
+	maxSize int64
+}
+
+func NewLocalStore(root string, maxSize int64) BlobStore {
+	err := os.MkdirAll(filepath.Join(root, TMP_DIR), 0o755)
+	if err != nil {
+		log.Fatalf("[init] -- (internal/storage) %s init failed.", root)
+	}
+	log.Printf("[init] -- (internal/storage) root: %s", root)
+	return &localStore{
+		root:    root,
+		maxSize: maxSize,
+	}
+}
+
+func (s *localStore) path(id string) string {
+	return filepath.Join(s.root, id[:2], id[2:4], id)
+}
+
+func (s *localStore) Put(ctx context.Context, r io.Reader) (*Blob, error) {
+	tmp, err := os.CreateTemp(filepath.Join(s.root, TMP_DIR), "blob-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	h := sha256.New()
+	// 多读一个字节用于判断是否超过上限
+	size, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > s.maxSize {
+		return nil, ErrBlobTooLarge
+	}
+	head := make([]byte, SNIFF_SIZE)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	blob := &Blob{
+		Id:       hex.EncodeToString(h.Sum(nil)),
+		Size:     size,
+		MimeType: http.DetectContentType(head[:n]),
+	}
+	path := s.path(blob.Id)
+	if _, err := os.Stat(path); err == nil {
+		return blob, nil
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+func (s *localStore) Open(ctx context.Context, id string) (io.ReadSeekCloser, *Blob, error) {
+	if !ValidId(id) {
+		return nil, nil, ErrBlobInvalid
+	}
+	f, err := os.Open(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	head := make([]byte, SNIFF_SIZE)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		f.Close()
+		return nil, nil, err
+	}
+	blob := &Blob{
+		Id:       id,
+		Size:     info.Size(),
+		MimeType: http.DetectContentType(head[:n]),
+	}
+	return f, blob, nil
+}
+
+func (s *localStore) Exists(ctx context.Context, id string) (bool, error) {
+	if !ValidId(id) {
+		return false, nil
+	}
+	_, err := os.Stat(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	UPLOAD_ID_FORMAT = `^[0-9a-f]{32}$`
+)
+
+var (
+	ErrPartTooLarge  = errors.New("part too large")
+	ErrUploadInvalid = errors.New("upload id invalid")
+)
+
+var uploadIdRegex = regexp.MustCompile(UPLOAD_ID_FORMAT)
+
+// 分片上传的临时分片存储，完成后拼接写入 BlobStore
+type PartStore interface {
+	WritePart(uploadId string, part int, r io.Reader, maxSize int64) (int64, error)
+	Open(uploadId string, partCount int) (io.ReadCloser, error)
+	Remove(uploadId string) error
+	Sweep(ttl time.Duration) int
+}
+
+// 本地磁盘分片: root/<uploadId>/<part>
+type localPartStore struct {
+	root string
+}
+
+func NewLocalPartStore(root string) PartStore {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		log.Fatalf("[init] -- (internal/storage) %s init failed.", root)
+	}
+	log.Printf("[init] -- (internal/storage) parts: %s", root)
+	return &localPartStore{
+		root: root,
+	}
+}
+
+func ValidUploadId(uploadId string) bool {
+	return uploadIdRegex.MatchString(uploadId)
+}
+
+func (s *localPartStore) dir(uploadId string) string {
+	return filepath.Join(s.root, uploadId)
+}
+
+// 写入分片，重复上传同一分片时覆盖
+func (s *localPartStore) WritePart(uploadId string, part int, r io.Reader, maxSize int64) (int64, error) {
+	if !ValidUploadId(uploadId) {
+		return -1, ErrUploadInvalid
+	}
+	dir := s.dir(uploadId)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return -1, err
+	}
+	tmp, err := os.CreateTemp(dir, "part-*")
+	if err != nil {
+		return -1, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return -1, err
+	}
+	if size > maxSize {
+		return -1, ErrPartTooLarge
+	}
+	err = tmp.Close()
+	if err != nil {
+		return -1, err
+	}
+	err = os.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(part)))
+	if err != nil {
+		return -1, err
+	}
+	return size, nil
+}
+
+// 按分片序号顺序拼接读取
+func (s *localPartStore) Open(uploadId string, partCount int) (io.ReadCloser, error) {
+	if !ValidUploadId(uploadId) {
+		return nil, ErrUploadInvalid
+	}
+	files := make([]*os.File, 0, partCount)
+	readers := make([]io.Reader, 0, partCount)
+	for part := 1; part <= partCount; part++ {
+		f, err := os.Open(filepath.Join(s.dir(uploadId), strconv.Itoa(part)))
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+	return &multiReadCloser{
+		Reader: io.MultiReader(readers...),
+		files:  files,
+	}, nil
+}
+
+func (s *localPartStore) Remove(uploadId string) error {
+	if !ValidUploadId(uploadId) {
+		return ErrUploadInvalid
+	}
+	return os.RemoveAll(s.dir(uploadId))
+}
+
+// 删除超过 ttl 未再写入分片的上传目录，返回清理数量
+func (s *localPartStore) Sweep(ttl time.Duration) int {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		log.Printf("[task] -- (internal/storage) sweep: %v", err)
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !ValidUploadId(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < ttl {
+			continue
+		}
+		if os.RemoveAll(s.dir(entry.Name())) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+type multiReadCloser struct {
+	io.Reader
+	files []*os.File
+}
+
+func (m *multiReadCloser) Close() error {
+	var err error
+	for _, f := range m.files {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
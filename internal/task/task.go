@@ -0,0 +1,24 @@
+package task
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// 按固定间隔在后台执行任务，ctx 取消后退出
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context)) {
+	log.Printf("[init] -- (internal/task) %s every: %v", name, interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -19,9 +20,14 @@ const (
 	REQUIRED = "required"
 	EMAIL    = "email"
 	NUMBER   = "number"
+	URL      = "url"
+	MIME     = "mime"
+	BLOB     = "blob"
 
 	EMAIL_FROMAT  = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
 	NUMBER_FORMAT = `^\d+$`
+	MIME_FORMAT   = `^[a-z]+/[a-zA-Z0-9.+-]+$`
+	BLOB_FORMAT   = `^[0-9a-f]{64}$`
 )
 
 type validatorError struct {
@@ -79,6 +85,8 @@ func (va *Validator) registerBuiltinValidators() {
 			return len(t) <= maxValue
 		case int:
 			return t <= maxValue
+		case int64:
+			return t <= int64(maxValue)
 		}
 		return false
 	}
@@ -102,6 +110,45 @@ func (va *Validator) registerBuiltinValidators() {
 		numberRegex := regexp.MustCompile(NUMBER_FORMAT)
 		return numberRegex.MatchString(number)
 	}
+
+	// 检测是否为 http(s) 链接，空值交给 required 判断
+	va.validators[URL] = func(value any, param string) bool {
+		link, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if link == "" {
+			return true
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			return false
+		}
+		return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	}
+
+	// 检测是否符合 type/subtype 格式的 mime 类型
+	va.validators[MIME] = func(value any, param string) bool {
+		mime, ok := value.(string)
+		if !ok {
+			return false
+		}
+		mimeRegex := regexp.MustCompile(MIME_FORMAT)
+		return mimeRegex.MatchString(mime)
+	}
+
+	// 检测是否为附件存储的 blob id(sha256 十六进制)，空值交给 required 判断
+	va.validators[BLOB] = func(value any, param string) bool {
+		blobId, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if blobId == "" {
+			return true
+		}
+		blobRegex := regexp.MustCompile(BLOB_FORMAT)
+		return blobRegex.MatchString(blobId)
+	}
 }
 
 func isEmpty(value any) bool {
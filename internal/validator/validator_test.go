@@ -90,3 +90,31 @@ func TestFail3(t *testing.T) {
 		log.Printf("-- test3 passed")
 	}
 }
+
+type payload struct {
+	Url      string `valid:"url"`
+	MimeType string `valid:"required,mime"`
+	Size     int64  `valid:"min=1,max=1024"`
+}
+
+func TestPayload(t *testing.T) {
+	t.Parallel()
+	va := NewValidator()
+	cases := []struct {
+		p  payload
+		ok bool
+	}{
+		{payload{Url: "https://example.com/a.png", MimeType: "image/png", Size: 10}, true},
+		{payload{Url: "", MimeType: "video/mp4", Size: 1024}, true},
+		{payload{Url: "ftp://example.com/a.png", MimeType: "image/png", Size: 10}, false},
+		{payload{Url: "https://", MimeType: "image/png", Size: 10}, false},
+		{payload{Url: "https://example.com", MimeType: "png", Size: 10}, false},
+		{payload{Url: "https://example.com", MimeType: "image/png", Size: 1025}, false},
+	}
+	for _, c := range cases {
+		oerr := va.Check(c.p)
+		if (oerr == nil) != c.ok {
+			t.Errorf("-- %+v expect %v got %v", c.p, c.ok, oerr)
+		}
+	}
+}
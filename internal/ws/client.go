@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	WRITE_WAIT       = 10 * time.Second   // 单次写超时
+	PONG_WAIT        = 60 * time.Second   // 等待客户端心跳的最长时间
+	PING_PERIOD      = PONG_WAIT * 9 / 10 // 服务端主动 ping 的周期，需小于 PONG_WAIT
+	MAX_MESSAGE_SIZE = 4096               // 客户端上行帧大小上限
+	SEND_QUEUE_SIZE  = 256                // 每个连接的写队列长度
+)
+
+// 单个 websocket 连接
+type Client struct {
+	hub    Hub
+	conn   *websocket.Conn
+	userId int64
+
+	mu     sync.Mutex
+	send   chan []byte
+	closed bool
+}
+
+func NewClient(hub Hub, conn *websocket.Conn, userId int64) *Client {
+	return &Client{
+		hub:    hub,
+		conn:   conn,
+		userId: userId,
+		send:   make(chan []byte, SEND_QUEUE_SIZE),
+	}
+}
+
+func (c *Client) UserId() int64 {
+	return c.userId
+}
+
+// 非阻塞入队，队列已满或已关闭时返回 false
+func (c *Client) enqueue(payload []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) closeSend() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// 注册连接并启动读写协程，阻塞直到连接断开
+func (c *Client) Serve() {
+	c.hub.Register(c)
+	go c.writePump()
+	c.readPump()
+}
+
+// 读协程: 处理心跳，连接断开后注销
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.Unregister(c)
+		c.conn.Close()
+	}()
+	c.conn.SetReadLimit(MAX_MESSAGE_SIZE)
+	c.conn.SetReadDeadline(time.Now().Add(PONG_WAIT))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(PONG_WAIT))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("[ws] -- (client) read userId: %d error: %v\n", c.userId, err)
+			}
+			return
+		}
+		// 任意上行帧都视为存活
+		c.conn.SetReadDeadline(time.Now().Add(PONG_WAIT))
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		// 应用层心跳，兼容无法发送 ping 控制帧的客户端
+		if event.Type == EVENT_PING {
+			payload, _ := json.Marshal(&Event{Type: EVENT_PONG})
+			c.enqueue(payload)
+		}
+	}
+}
+
+// 写协程: 串行消费写队列并定时发送 ping
+func (c *Client) writePump() {
+	ticker := time.NewTicker(PING_PERIOD)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case payload, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
+			if !ok {
+				// 写队列被关闭，通知客户端断开
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				c.hub.Unregister(c)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.hub.Unregister(c)
+				return
+			}
+		}
+	}
+}
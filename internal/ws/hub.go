@@ -0,0 +1,106 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// 推送事件类型
+const (
+	EVENT_PING    = "ping"
+	EVENT_PONG    = "pong"
+	EVENT_MESSAGE = "message"
+	EVENT_READ    = "read"
+	EVENT_RECALL  = "recall"
+	EVENT_JOIN    = "join" // 入群申请处理结果
+)
+
+// 推送给客户端的统一事件格式
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type Hub interface {
+	Register(client *Client)
+	Unregister(client *Client)
+	Push(userId int64, event *Event) bool
+	IsOnline(userId int64) bool
+}
+
+// 在线连接注册表 userId -> 该用户的所有连接(多端登录)
+type hub struct {
+	mu      sync.RWMutex
+	clients map[int64]map[*Client]struct{}
+}
+
+func NewHub() Hub {
+	defer log.Printf("[init] -- (internal/ws) status: success\n")
+	return &hub{
+		clients: make(map[int64]map[*Client]struct{}),
+	}
+}
+
+func (h *hub) Register(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conns, ok := h.clients[client.userId]
+	if !ok {
+		conns = make(map[*Client]struct{})
+		h.clients[client.userId] = conns
+	}
+	conns[client] = struct{}{}
+	log.Printf("[ws] -- (hub) register userId: %d conns: %d\n", client.userId, len(conns))
+}
+
+// 注销连接并关闭写队列，重复注销无副作用
+func (h *hub) Unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conns, ok := h.clients[client.userId]
+	if !ok {
+		return
+	}
+	if _, ok := conns[client]; !ok {
+		return
+	}
+	delete(conns, client)
+	if len(conns) == 0 {
+		delete(h.clients, client.userId)
+	}
+	client.closeSend()
+	log.Printf("[ws] -- (hub) unregister userId: %d conns: %d\n", client.userId, len(conns))
+}
+
+// 推送事件到用户的所有在线连接，用户不在线时返回 false
+func (h *hub) Push(userId int64, event *Event) bool {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[ws] -- (hub) marshal event fail: %v\n", err)
+		return false
+	}
+	h.mu.RLock()
+	conns := make([]*Client, 0, len(h.clients[userId]))
+	for client := range h.clients[userId] {
+		conns = append(conns, client)
+	}
+	h.mu.RUnlock()
+	if len(conns) == 0 {
+		return false
+	}
+	for _, client := range conns {
+		// 写队列已满说明客户端消费过慢，直接断开由客户端重连后走离线同步
+		if !client.enqueue(payload) {
+			log.Printf("[ws] -- (hub) send queue full userId: %d\n", userId)
+			h.Unregister(client)
+		}
+	}
+	return true
+}
+
+func (h *hub) IsOnline(userId int64) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userId]) > 0
+}
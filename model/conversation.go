@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// 会话列表中的一项，由单聊或群成员关系、最后一条消息以及 redis 中的未读数与置顶状态合并而成
+type Conversation struct {
+	DialogType  int          `json:"dialogType"`            // 会话类型
+	DialogId    int64        `json:"dialogId"`              // 单聊为对方账号，群聊为群号
+	SingleId    int64        `json:"singleId"`              // 单聊标识，群聊为 0
+	Name        string       `json:"name"`                  // 单聊为备注或用户名，群聊为群别称或群名
+	Avatar      string       `json:"avatar"`                // 对方头像或群头像
+	Disturb     int          `json:"disturb"`               // 自己的打扰模式
+	Pinned      bool         `json:"pinned"`                // 是否置顶
+	UnreadCount int64        `json:"unreadCount"`           // 未读消息数
+	Preview     string       `json:"preview"`               // 最后一条消息预览
+	LastMessage *MessageItem `json:"lastMessage,omitempty"` // 最后一条消息，没有消息时为空
+	LastTime    time.Time    `json:"lastTime"`              // 最后活跃时间，没有消息时为建立单聊或入群的时间
+}
+
+type GetConversationsReq struct {
+	TagId int64 `json:"tagId"` // 按标签筛选，0 表示不筛选
+}
+
+type PinConversationReq struct {
+	DialogType int   `json:"dialogType" valid:"required,min=1,max=2"`
+	DialogId   int64 `json:"dialogId" valid:"required"`
+	Pinned     bool  `json:"pinned"`
+}
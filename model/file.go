@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+type UploadRes struct {
+	BlobId   string `json:"blobId"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// 分片上传任务
+type Upload struct {
+	UploadId    string    `json:"uploadId"`    // 上传任务id
+	Size        int64     `json:"size"`        // 文件总大小
+	PartCount   int       `json:"partCount"`   // 分片数量
+	CreatedTime time.Time `json:"createdTime"` // 创建时间
+}
+
+type UploadPart struct {
+	Part int   `json:"part"`
+	Size int64 `json:"size"`
+}
+
+type InitUploadReq struct {
+	Size      int64 `json:"size" valid:"required,min=1"`
+	PartCount int   `json:"partCount" valid:"required,min=1,max=10000"`
+}
+
+type InitUploadRes struct {
+	UploadId   string    `json:"uploadId"`
+	Size       int64     `json:"size"`
+	PartCount  int       `json:"partCount"`
+	ExpireTime time.Time `json:"expireTime"`
+}
+
+type UploadPartRes struct {
+	UploadId string `json:"uploadId"`
+	Part     int    `json:"part"`
+	Size     int64  `json:"size"`
+}
+
+type UploadPartsRes struct {
+	UploadId  string        `json:"uploadId"`
+	Size      int64         `json:"size"`
+	PartCount int           `json:"partCount"`
+	Parts     []*UploadPart `json:"parts"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // entity for group and group_detail table
 type Group struct {
 	GroupId          int64  `json:"groupId"`          // 群id
@@ -13,31 +15,34 @@ type Group struct {
 }
 
 type GroupToUser struct {
-	GroupId            int64  `json:"groupId"`          // 群号
-	GroupAvatar        string `json:"groupAvatar"`      // 群头像
-	GroupName          string `json:"groupName"`        // 群名称
-	GroupNickname      string `json:"groupNickname"`    // 群别称
-	GroupMaxSize       int    `json:"groupMaxSize"`     // 群最大容量
-	GroupCurrentSize   int    `json:"groupCurrentSize"` // 群当前容量
-	UserId             int64  `json:"userId"`           // 用户账号
-	UserName           string `json:"userName"`         // 用户名
-	UserNickname       string `json:"userNickname"`     // 用户别称
-	UserRoleId         int    `json:"userRoleId"`       // 用户职责id
-	UserRole           string `json:"userRole"`         // 用户职责
-	UserRoleNickname   string `json:"userRoleNickname"` // 用户职责别称
-	UserDisturb        int    `json:"userDisturb"`      // 用户打扰模式
-	IsSetRole          bool   `json:"isSetRole"`
-	IsSetDisturb       bool   `json:"isSetDisturb"`
-	IsSetUserNickname  bool   `json:"isSetUserNickname"`
-	IsSetGroupNickname bool   `json:"isSetGroupNickname"`
+	GroupId            int64              `json:"groupId"`          // 群号
+	GroupAvatar        string             `json:"groupAvatar"`      // 群头像
+	GroupName          string             `json:"groupName"`        // 群名称
+	GroupNickname      string             `json:"groupNickname"`    // 群别称
+	GroupMaxSize       int                `json:"groupMaxSize"`     // 群最大容量
+	GroupCurrentSize   int                `json:"groupCurrentSize"` // 群当前容量
+	UserId             int64              `json:"userId"`           // 用户账号
+	UserName           string             `json:"userName"`         // 用户名
+	UserNickname       string             `json:"userNickname"`     // 用户别称
+	UserRoleId         int                `json:"userRoleId"`       // 用户职责id
+	UserRole           string             `json:"userRole"`         // 用户职责
+	UserRoleNickname   string             `json:"userRoleNickname"` // 用户职责别称
+	UserDisturb        int                `json:"userDisturb"`      // 用户打扰模式
+	MuteUntil          time.Time          `json:"muteUntil"`        // 禁言截止时间，零值表示未禁言
+	Announcement       *GroupAnnouncement `json:"announcement"`     // 当前置顶公告
+	IsSetRole          bool               `json:"isSetRole"`
+	IsSetDisturb       bool               `json:"isSetDisturb"`
+	IsSetUserNickname  bool               `json:"isSetUserNickname"`
+	IsSetGroupNickname bool               `json:"isSetGroupNickname"`
 }
 
 type GroupToUserItem struct {
-	UserId           int64  `json:"userId"`
-	UserName         string `json:"userName"`
-	UserNickname     string `json:"userNickname"`
-	UserRole         string `json:"userRole"`
-	UserRoleNickname string `json:"userRoleNickname"`
+	UserId           int64     `json:"userId"`
+	UserName         string    `json:"userName"`
+	UserNickname     string    `json:"userNickname"`
+	UserRole         string    `json:"userRole"`
+	UserRoleNickname string    `json:"userRoleNickname"`
+	MuteUntil        time.Time `json:"muteUntil"` // 禁言截止时间，零值表示未禁言
 }
 
 type GroupBasic struct {
@@ -60,9 +65,8 @@ type GroupUser struct {
 
 type CreateGroupReq struct {
 	GroupName     string `json:"groupName"`
-	GroupPassword string `json:"groupPassword"`
+	GroupPassword string `json:"groupPassword" valid:"max=20"`
 	GroupMaxSize  int    `json:"groupMaxSize"`
-	UserId        int64  `json:"userId"`
 	UserNickname  string `json:"userNickname"`
 }
 
@@ -74,24 +78,26 @@ type CreateGroupRes struct {
 }
 
 type JoinGroupReq struct {
-	GroupId      int64  `json:"groupId"`
-	UserId       int64  `json:"userId"`
-	UserNickname string `json:"userNickname"`
-	UserDisturb  int    `json:"userDisturb"`
+	GroupId       int64  `json:"groupId"`
+	GroupPassword string `json:"groupPassword"`
+	Message       string `json:"message" valid:"max=255"` // 需要审批时的申请附言
+	UserNickname  string `json:"userNickname"`
+	UserDisturb   int    `json:"userDisturb"`
 }
 
 type JoinGroupRes struct {
 	GroupId      int64  `json:"groupId"`
 	UserNickname string `json:"userNickname"`
 	UserDisturb  int    `json:"userDisturb"`
+	Pending      bool   `json:"pending"` // 已提交申请，等待审批
 }
 
 type UpdateGroupReq struct {
 	GroupId       int64  `json:"groupId"`
 	GroupName     string `json:"groupName"`
-	GroupPassword string `json:"groupPassword"`
+	GroupPassword string `json:"groupPassword" valid:"max=20"`
 	GroupMaxSize  int    `json:"groupMaxSize"`
-	GroupAvatar   string `json:"groupAvatar"`
+	GroupAvatar   string `json:"groupAvatar" valid:"blob"`
 }
 
 type UpdateGroupUserReq struct {
@@ -109,8 +115,8 @@ type UpdateGroupUserReq struct {
 }
 
 type SearchGroupReq struct {
-	CurrentPage int    `json:"currentPage"`
-	PageSize    int    `json:"pageSize"`
+	CurrentPage int    `json:"currentPage" valid:"required,min=1"`
+	PageSize    int    `json:"pageSize" valid:"required,min=1,max=50"`
 	GroupId     int64  `json:"groupId"`
 	GroupName   string `json:"groupName"`
 }
@@ -122,8 +128,8 @@ type GroupItem struct {
 
 type SearchGroupUsersReq struct {
 	GroupId      int64  `json:"groupId"`
-	CurrentPage  int    `json:"currentPage"`
-	PageSize     int    `json:"pageSize"`
+	CurrentPage  int    `json:"currentPage" valid:"required,min=1"`
+	PageSize     int    `json:"pageSize" valid:"required,min=1,max=50"`
 	UserId       int64  `json:"userId"`
 	UserName     string `json:"userName"`
 	UserNickname string `json:"userNickname"`
@@ -131,8 +137,180 @@ type SearchGroupUsersReq struct {
 
 type GetGroupUsersReq struct {
 	GroupId     int64 `json:"groupId"`
-	CurrentPage int   `json:"currentPage"`
-	PageSize    int   `json:"pageSize"`
+	CurrentPage int   `json:"currentPage" valid:"required,min=1"`
+	PageSize    int   `json:"pageSize" valid:"required,min=1,max=50"`
+}
+
+// 群公告
+type GroupAnnouncement struct {
+	AnnouncementId int64     `json:"announcementId"` // 公告标识
+	GroupId        int64     `json:"groupId"`        // 群号
+	CreatorId      int64     `json:"creatorId"`      // 发布人账号
+	Title          string    `json:"title"`          // 公告标题
+	Content        string    `json:"content"`        // 公告内容
+	Pinned         bool      `json:"pinned"`         // 是否置顶
+	NeedAck        bool      `json:"needAck"`        // 是否需要成员确认
+	Acked          bool      `json:"acked"`          // 当前用户是否已确认
+	AckCount       int       `json:"ackCount"`       // 已确认人数
+	CreatedTime    time.Time `json:"createdTime"`    // 发布时间
+	UpdatedTime    time.Time `json:"updatedTime"`    // 修改时间
+}
+
+type GetGroupDetailReq struct {
+	GroupId int64 `json:"groupId"`
+}
+
+type GetGroupDetailRes struct {
+	GroupId          int64              `json:"groupId"`
+	GroupName        string             `json:"groupName"`
+	GroupNickname    string             `json:"groupNickname"`
+	GroupAvatar      string             `json:"groupAvatar"`
+	GroupMaxSize     int                `json:"groupMaxSize"`
+	GroupCurrentSize int                `json:"groupCurrentSize"`
+	UserNickname     string             `json:"userNickname"`
+	UserRole         string             `json:"userRole"`
+	UserDisturb      int                `json:"userDisturb"`
+	MuteUntil        time.Time          `json:"muteUntil"`
+	Announcement     *GroupAnnouncement `json:"announcement"`
+}
+
+type CreateAnnouncementReq struct {
+	GroupId int64  `json:"groupId"`
+	Title   string `json:"title" valid:"required,max=64"`
+	Content string `json:"content" valid:"required,max=2048"`
+	Pinned  bool   `json:"pinned"`
+	NeedAck bool   `json:"needAck"`
+}
+
+type UpdateAnnouncementReq struct {
+	AnnouncementId int64  `json:"announcementId" valid:"required"`
+	Title          string `json:"title" valid:"required,max=64"`
+	Content        string `json:"content" valid:"required,max=2048"`
+	Pinned         bool   `json:"pinned"`
+	NeedAck        bool   `json:"needAck"`
+}
+
+type AnnouncementReq struct {
+	AnnouncementId int64 `json:"announcementId" valid:"required"`
+}
+
+type GetAnnouncementsReq struct {
+	GroupId     int64 `json:"groupId"`
+	CurrentPage int   `json:"currentPage" valid:"required,min=1"`
+	PageSize    int   `json:"pageSize" valid:"required,min=1,max=50"`
+}
+
+// 群封禁
+type GroupBan struct {
+	GroupId     int64     `json:"groupId"`     // 群号
+	UserId      int64     `json:"userId"`      // 被封禁用户账号
+	UserName    string    `json:"userName"`    // 被封禁用户名
+	OperatorId  int64     `json:"operatorId"`  // 操作人账号
+	Reason      string    `json:"reason"`      // 封禁原因
+	ExpireTime  time.Time `json:"expireTime"`  // 封禁截止时间，零值表示永久
+	CreatedTime time.Time `json:"createdTime"` // 封禁时间
+}
+
+type BanGroupUserReq struct {
+	GroupId       int64  `json:"groupId"`
+	UserId        int64  `json:"userId" valid:"required"`
+	Reason        string `json:"reason" valid:"max=255"`
+	ExpireSeconds int64  `json:"expireSeconds"` // 封禁时长，0 表示永久
+}
+
+type UnbanGroupUserReq struct {
+	GroupId int64 `json:"groupId"`
+	UserId  int64 `json:"userId" valid:"required"`
+}
+
+type GetGroupBansReq struct {
+	GroupId     int64 `json:"groupId"`
+	CurrentPage int   `json:"currentPage" valid:"required,min=1"`
+	PageSize    int   `json:"pageSize" valid:"required,min=1,max=50"`
+}
+
+type MuteGroupUserReq struct {
+	GroupId     int64 `json:"groupId"`
+	UserId      int64 `json:"userId" valid:"required"`
+	MuteSeconds int64 `json:"muteSeconds"` // 禁言时长，0 表示解除禁言
+}
+
+type TransferGroupReq struct {
+	GroupId     int64  `json:"groupId"`
+	SuccessorId int64  `json:"successorId"`
+	DemoteRole  string `json:"demoteRole"` // 原群主降级后的角色: admin 或 joiner，默认 admin
+}
+
+// 群邀请码
+type GroupInvite struct {
+	Code        string    `json:"code"`        // 邀请码
+	GroupId     int64     `json:"groupId"`     // 群号
+	CreatorId   int64     `json:"creatorId"`   // 创建人
+	Role        string    `json:"role"`        // 加入后的角色
+	MaxUses     int       `json:"maxUses"`     // 最大使用次数，0 表示不限
+	Uses        int       `json:"uses"`        // 已使用次数
+	ExpireTime  time.Time `json:"expireTime"`  // 过期时间，零值表示不过期
+	CreatedTime time.Time `json:"createdTime"` // 创建时间
+}
+
+type CreateInviteReq struct {
+	GroupId       int64  `json:"groupId"`
+	Role          string `json:"role"` // admin 或 joiner，默认 joiner
+	MaxUses       int    `json:"maxUses" valid:"min=0"`
+	ExpireSeconds int64  `json:"expireSeconds" valid:"min=0"` // 有效期，0 表示不过期
+}
+
+type GetInvitesReq struct {
+	GroupId int64 `json:"groupId"`
+}
+
+type RevokeInviteReq struct {
+	Code string `json:"code" valid:"required,max=32"`
+}
+
+type JoinByCodeReq struct {
+	Code         string `json:"code" valid:"required,max=32"`
+	UserNickname string `json:"userNickname"`
+	UserDisturb  int    `json:"userDisturb"`
+}
+
+// 入群申请
+type JoinRequest struct {
+	RequestId    int64     `json:"requestId"`    // 申请标识
+	GroupId      int64     `json:"groupId"`      // 群号
+	UserId       int64     `json:"userId"`       // 申请人账号
+	UserName     string    `json:"userName"`     // 申请人用户名
+	UserNickname string    `json:"userNickname"` // 申请人群别称
+	Message      string    `json:"message"`      // 申请附言
+	Status       int       `json:"status"`       // 申请状态
+	HandlerId    int64     `json:"handlerId"`    // 处理人账号
+	CreatedTime  time.Time `json:"createdTime"`  // 申请时间
+}
+
+type GetJoinRequestsReq struct {
+	GroupId     int64 `json:"groupId"`
+	CurrentPage int   `json:"currentPage" valid:"required,min=1"`
+	PageSize    int   `json:"pageSize" valid:"required,min=1,max=50"`
+}
+
+type HandleJoinRequestReq struct {
+	RequestId int64 `json:"requestId" valid:"required"`
+}
+
+type SetJoinPolicyReq struct {
+	GroupId    int64  `json:"groupId"`
+	JoinPolicy string `json:"joinPolicy" valid:"required"`
+}
+
+type GetGroupPermissionReq struct {
+	GroupId int64 `json:"groupId"`
+}
+
+type GroupPermissionRes struct {
+	GroupId     int64               `json:"groupId"`
+	UserRole    string              `json:"userRole"`
+	Actions     []string            `json:"actions"`     // 当前用户在该群可执行的操作
+	Permissions map[string][]string `json:"permissions"` // 操作 -> 允许的角色
 }
 
 type DeleteGroupUserReq struct {
@@ -1,14 +1,152 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // entity for message table
 type Message struct {
 	// Topic [string|int64] `json:"tupic"` // 消息kafka主题
-	MessageId int64  `json:"messageId"` // 消息id
-	Sender    int64  `json:"sender"`    // 消息发送者
-	Receiver  int64  `json:"receiver"`  // 消息接收者
-	Type      string `json:"type"`      // 消息类型
-	Text      string `json:"text"`      // 文本消息映射
-	Content   []byte `json:"content"`   // 二进制消息映射
-	Status    string `json:"status"`    // 消息状态
-	Deleted   int    `json:"deleted"`   // 消息逻辑删除
+	MessageId  int64           `json:"messageId"`  // 消息id
+	Sender     int64           `json:"sender"`     // 消息发送者
+	Receiver   int64           `json:"receiver"`   // 消息接收者
+	DialogType int             `json:"dialogType"` // 对话类型
+	Type       string          `json:"type"`       // 消息类型
+	Text       string          `json:"text"`       // 文本消息映射
+	Content    json.RawMessage `json:"content"`    // 非文本消息的 json 负载
+	BlobId     string          `json:"blobId"`     // 媒体消息引用的附件
+	Status     string          `json:"status"`     // 消息状态
+	SendTime   time.Time       `json:"sendTime"`   // 发送时间
+	Deleted    int             `json:"deleted"`    // 消息逻辑删除
+}
+
+// entity for timeline table
+type Timeline struct {
+	TimelineType int   `json:"timelineType"` // 时间线类型
+	TimelineId   int64 `json:"timelineId"`   // 时间线id
+	SequenceId   int64 `json:"sequenceId"`   // 时间线内序列号
+	Sender       int64 `json:"sender"`       // 发送者
+	DialogType   int   `json:"dialogType"`   // 对话类型
+	MessageId    int64 `json:"messageId"`    // 消息id
+}
+
+// 推送给客户端的消息
+type MessageItem struct {
+	TimelineType int             `json:"timelineType"`
+	TimelineId   int64           `json:"timelineId"`
+	SequenceId   int64           `json:"sequenceId"`
+	MessageId    int64           `json:"messageId"`
+	Sender       int64           `json:"sender"`
+	Receiver     int64           `json:"receiver"`
+	DialogType   int             `json:"dialogType"`
+	Type         string          `json:"type"`
+	Text         string          `json:"text"`
+	Content      json.RawMessage `json:"content,omitempty"`
+	Status       string          `json:"status"`
+	ReadCount    int             `json:"readCount"`
+	SendTime     time.Time       `json:"sendTime"`
+}
+
+type SendSingleReq struct {
+	ReceiverId int64           `json:"receiverId" valid:"required,min=100000"`
+	Type       string          `json:"type" valid:"required"`
+	Text       string          `json:"text" valid:"max=2048"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type SendSingleRes struct {
+	MessageId  int64     `json:"messageId"`
+	SequenceId int64     `json:"sequenceId"`
+	SendTime   time.Time `json:"sendTime"`
+}
+
+type SendGroupReq struct {
+	GroupId int64           `json:"groupId" valid:"required,min=1000000"`
+	Type    string          `json:"type" valid:"required"`
+	Text    string          `json:"text" valid:"max=2048"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type SendGroupRes struct {
+	MessageId    int64     `json:"messageId"`
+	TimelineType int       `json:"timelineType"`
+	TimelineId   int64     `json:"timelineId"`
+	SequenceId   int64     `json:"sequenceId"`
+	SendTime     time.Time `json:"sendTime"`
+}
+
+type SyncCursor struct {
+	TimelineType int   `json:"timelineType"`
+	TimelineId   int64 `json:"timelineId"`
+	SequenceId   int64 `json:"sequenceId"`
+}
+
+type TimelinePage struct {
+	TimelineType int                       `json:"timelineType"`
+	TimelineId   int64                     `json:"timelineId"`
+	Page         *CursorPage[*MessageItem] `json:"page"`
+}
+
+type SyncReq struct {
+	PageSize int           `json:"pageSize" valid:"required,min=1,max=100"`
+	Cursors  []*SyncCursor `json:"cursors"`
+}
+
+type SyncRes struct {
+	Timelines []*TimelinePage `json:"timelines"`
+}
+
+// 已读回执，单聊推送给消息发送者
+type ReadReceipt struct {
+	ReaderId     int64     `json:"readerId"`
+	DialogType   int       `json:"dialogType"`
+	PeerId       int64     `json:"peerId"`
+	TimelineType int       `json:"timelineType"`
+	TimelineId   int64     `json:"timelineId"`
+	SequenceId   int64     `json:"sequenceId"`
+	MessageIds   []int64   `json:"messageIds"`
+	ReadTime     time.Time `json:"readTime"`
+}
+
+type MarkReadReq struct {
+	DialogType   int   `json:"dialogType" valid:"required,min=1,max=2"`
+	PeerId       int64 `json:"peerId" valid:"required,min=100000"`
+	TimelineType int   `json:"timelineType"`
+	TimelineId   int64 `json:"timelineId"`
+	SequenceId   int64 `json:"sequenceId" valid:"required,min=1"`
+}
+
+type MarkReadRes struct {
+	DialogType int     `json:"dialogType"`
+	PeerId     int64   `json:"peerId"`
+	SequenceId int64   `json:"sequenceId"`
+	MessageIds []int64 `json:"messageIds"`
+}
+
+// 撤回通知，推送给对话中的在线用户
+type RecallNotice struct {
+	MessageId  int64     `json:"messageId"`
+	OperatorId int64     `json:"operatorId"`
+	Sender     int64     `json:"sender"`
+	Receiver   int64     `json:"receiver"`
+	DialogType int       `json:"dialogType"`
+	RecallTime time.Time `json:"recallTime"`
+}
+
+type RecallReq struct {
+	MessageId int64 `json:"messageId" valid:"required,min=1"`
+}
+
+type RecallRes struct {
+	MessageId  int64     `json:"messageId"`
+	RecallTime time.Time `json:"recallTime"`
+}
+
+// 入库内容: 携带负载的消息存储 json 负载，文本消息存储原文
+func (m *Message) StoredContent() string {
+	if len(m.Content) > 0 {
+		return string(m.Content)
+	}
+	return m.Text
 }
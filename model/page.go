@@ -15,3 +15,21 @@ func NewPage[T any](currentPage, pageSize, total int, items []T) *Page[T] {
 		Total:       total,
 	}
 }
+
+// 基于游标的分页，按序列号递增拉取
+// 序列号在写入事务内分配且时间线持锁到提交，游标之后提交的消息序列号一定更大，并发插入不会导致重复或遗漏
+type CursorPage[T any] struct {
+	Cursor   int64 `json:"cursor"`
+	PageSize int   `json:"pageSize"`
+	HasMore  bool  `json:"hasMore"`
+	Items    []T   `json:"items"`
+}
+
+func NewCursorPage[T any](cursor int64, pageSize int, hasMore bool, items []T) *CursorPage[T] {
+	return &CursorPage[T]{
+		Cursor:   cursor,
+		PageSize: pageSize,
+		HasMore:  hasMore,
+		Items:    items,
+	}
+}
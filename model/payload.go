@@ -0,0 +1,19 @@
+package model
+
+// 图片、音频、视频消息负载，文件先上传到附件存储再引用其 blob id
+type MediaPayload struct {
+	BlobId   string `json:"blobId" valid:"required,blob"`
+	Size     int64  `json:"size" valid:"required,min=1"`
+	MimeType string `json:"mimeType" valid:"required,mime"`
+	Duration int    `json:"duration" valid:"min=0"` // 音视频时长，单位秒
+	Width    int    `json:"width" valid:"min=0"`
+	Height   int    `json:"height" valid:"min=0"`
+}
+
+// 链接消息负载，标题等信息由客户端展开后携带
+type LinkPayload struct {
+	Url         string `json:"url" valid:"required,url,max=2048"`
+	Title       string `json:"title" valid:"max=256"`
+	Description string `json:"description" valid:"max=512"`
+	Image       string `json:"image" valid:"url,max=2048"`
+}
@@ -3,17 +3,28 @@ package model
 import (
 	"database/sql"
 
+	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
 	"github.com/redis/go-redis/v9"
+	"github.com/wendisx/gorchat/config"
 	"github.com/wendisx/gorchat/config/middleware"
 	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/internal/storage"
+	"github.com/wendisx/gorchat/internal/validator"
+	"github.com/wendisx/gorchat/internal/ws"
 )
 
 type Dependency struct {
 	Echo        *echo.Echo
+	Env         config.Env
 	Database    *sql.DB
 	Logger      log.Logger
 	RedisClient *redis.Client
 	Response    Response
 	MiddleWare  middleware.Middleware
+	Session     sessions.Store
+	Validator   *validator.Validator
+	Hub         ws.Hub
+	BlobStore   storage.BlobStore
+	PartStore   storage.PartStore
 }
@@ -11,6 +11,7 @@ type Single struct {
 	InviteeNickname string    `json:"inviteeNickname"` // 被邀请人别称
 	InviterDisturb  int       `json:"inviterDisturb"`  // 邀请人打扰模式
 	InviteeDisturb  int       `json:"inviteeDisturb"`  // 被邀请人打扰模式
+	Status          int       `json:"status"`          // 申请状态
 	CreateTime      time.Time `json:"createTime"`      // 单聊创建时间
 	Deleted         int       `json:"deleted"`         // 逻辑删除
 }
@@ -21,6 +22,7 @@ type SingleInvite struct {
 	InviteeId       int64  `json:"inviteeId"`
 	InviteeNickname string `json:"inviteeNickname"`
 	InviterDisturb  int    `json:"inviterDisturb"`
+	Status          int    `json:"status"`
 	Deleted         int    `json:"deleted"`
 }
 
@@ -29,9 +31,23 @@ type SingleAccept struct {
 	InviteeId       int64  `json:"inviteeId"`
 	InviterNickname string `json:"inviterNickname"`
 	InviteeDisturb  int    `json:"inviteeDisturb"`
+	FromStatus      int    `json:"fromStatus"`
+	Status          int    `json:"status"`
 	Deleted         int    `json:"deleted"`
 }
 
+// 单聊申请
+type SingleRequest struct {
+	SingleId        int64     `json:"singleId"`        // 单聊id
+	InviterId       int64     `json:"inviterId"`       // 邀请人id
+	InviterName     string    `json:"inviterName"`     // 邀请人用户名
+	InviteeId       int64     `json:"inviteeId"`       // 被邀请人id
+	InviteeName     string    `json:"inviteeName"`     // 被邀请人用户名
+	InviteeNickname string    `json:"inviteeNickname"` // 邀请人为被邀请人设置的别称
+	Status          int       `json:"status"`          // 申请状态
+	CreatedTime     time.Time `json:"createdTime"`     // 申请时间
+}
+
 type SingleInviter struct {
 	SingleId        int64  `json:"singleId"`
 	InviterId       int64  `json:"inviterId"`
@@ -57,7 +73,6 @@ type SingleDelete struct {
 }
 
 type InviteReq struct {
-	InviterId       int64  `json:"inviterId"`
 	InviteeId       int64  `json:"inviteeId"`
 	InviteeNickname string `json:"inviteeNickname"`
 	InviterDisturb  int    `json:"inviterDisturb"`
@@ -73,7 +88,6 @@ type InviteRes struct {
 
 type AcceptReq struct {
 	SingleId        int64  `json:"singleId"`
-	InviteeId       int64  `json:"inviteeId"`
 	InviterNickname string `json:"inviterNickname"`
 	InviteeDisturb  int    `json:"inviteeDisturb"`
 }
@@ -86,10 +100,34 @@ type AcceptRes struct {
 	InviteeDisturb  int    `json:"inviteeDisturb"`
 }
 
+// 单聊联系人，字段均为对方的信息
+type Contact struct {
+	SingleId     int64  `json:"singleId"`     // 单聊id
+	UserId       int64  `json:"userId"`       // 对方账号
+	UserName     string `json:"userName"`     // 对方用户名
+	UserNickname string `json:"userNickname"` // 为对方设置的备注
+	UserDisturb  int    `json:"userDisturb"`  // 自己的打扰模式
+	UserAvatar   string `json:"userAvatar"`   // 对方头像
+}
+
+type GetContactsReq struct {
+	CurrentPage int   `json:"currentPage" valid:"required,min=1"`
+	PageSize    int   `json:"pageSize" valid:"required,min=1,max=50"`
+	TagId       int64 `json:"tagId"` // 按标签筛选，0 表示不筛选
+}
+
+type GetRequestsReq struct {
+	CurrentPage int `json:"currentPage" valid:"required,min=1"`
+	PageSize    int `json:"pageSize" valid:"required,min=1,max=50"`
+}
+
+type RequestReq struct {
+	SingleId int64 `json:"singleId" valid:"required"`
+}
+
 type UpdateNicknameReq struct {
 	SingleId    int64  `json:"singleId"`
 	IsInviter   bool   `json:"isInviter"`
-	UserId      int64  `json:"userId"`
 	SetNickname string `json:"setNickname"`
 	UserDisturb int    `json:"userDisturb"`
 }
@@ -104,7 +142,6 @@ type UpdateNicknameRes struct {
 type UpdateDisturbReq struct {
 	SingleId     int64  `json:"singleId"`
 	IsInviter    bool   `json:"isInviter"`
-	UserId       int64  `json:"userId"`
 	SetDisturb   int    `json:"userDisturb"`
 	UserNickname string `json:"userNickname"`
 }
@@ -117,7 +154,6 @@ type UpdateDisturbRes struct {
 type GetDetailReq struct {
 	SingleId  int64 `json:"singleId"`
 	IsInviter bool  `json:"isInviter"`
-	UserId    int64 `json:"userId"`
 }
 
 type GetDetailRes struct {
@@ -130,6 +166,6 @@ type GetDetailRes struct {
 
 type DeleteReq struct {
 	SingleId  int64 `json:"singleId"`
-	InviterId int64 `json:"inviterId"`
-	InviteeId int64 `json:"inviteeId"`
+	IsInviter bool  `json:"isInviter"`
+	PeerId    int64 `json:"peerId"`
 }
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// entity for im_tag table
+type Tag struct {
+	TagId       int64     `json:"tagId"`       // 标签标识
+	UserId      int64     `json:"userId"`      // 标签所属用户账号
+	TagName     string    `json:"tagName"`     // 标签名
+	ItemCount   int       `json:"itemCount"`   // 关联的会话数量
+	CreatedTime time.Time `json:"createdTime"` // 创建时间
+}
+
+// entity for im_tag_item table
+type TagItem struct {
+	TagId      int64 `json:"tagId"`      // 标签标识
+	DialogType int   `json:"dialogType"` // 会话类型
+	DialogId   int64 `json:"dialogId"`   // 单聊标识或群号
+}
+
+type CreateTagReq struct {
+	TagName string `json:"tagName" valid:"required,max=32"`
+}
+
+type UpdateTagReq struct {
+	TagId   int64  `json:"tagId" valid:"required"`
+	TagName string `json:"tagName" valid:"required,max=32"`
+}
+
+type TagReq struct {
+	TagId int64 `json:"tagId" valid:"required"`
+}
+
+// 标签关联的是对话本身: 单聊的 dialogId 为 singleId(会话列表项中的 singleId 字段)，群聊为群号
+// 注意会话列表、置顶与未读接口中单聊的 dialogId 为对方账号，两者不能混用
+type TagItemReq struct {
+	TagId      int64 `json:"tagId" valid:"required"`
+	DialogType int   `json:"dialogType" valid:"required,min=1,max=2"`
+	DialogId   int64 `json:"dialogId" valid:"required"` // 单聊为 singleId，群聊为群号
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // entity for user and user_detail table
 type User struct {
 	UserId       int64  `json:"userId"`       // 用户账号
@@ -32,6 +34,7 @@ type SignupRes struct {
 type LoginReq struct {
 	UserId       int64  `json:"userId" valid:"required,min=100000"`
 	UserPassword string `json:"userPassword" valid:"required,min=8,max=20"`
+	Device       string `json:"device" valid:"max=64"`
 }
 
 type LoginRes struct {
@@ -47,7 +50,6 @@ type LoginRes struct {
 }
 
 type UpdateInfoReq struct {
-	UserId       int64  `json:"userId" valid:"required,min=100000"`
 	UserName     string `json:"userName"`
 	UserEmail    string `json:"userEmail"`
 	UserPhone    string `json:"userPhone"`
@@ -55,7 +57,7 @@ type UpdateInfoReq struct {
 	UserAge      int    `json:"userAge"`
 	UserAddress  string `json:"userAddress"`
 	UserLocation string `json:"userLocation"`
-	UserAvatar   string `json:"userAvatar"`
+	UserAvatar   string `json:"userAvatar" valid:"blob"`
 }
 
 type UpdateInfoRes struct {
@@ -94,3 +96,18 @@ type SearchUserRes struct {
 	Total       int         `json:"total"`
 	Items       []UserBasic `json:"items"`
 }
+
+type UserBlock struct {
+	UserId      int64     `json:"userId"`      // 被屏蔽用户账号
+	UserName    string    `json:"userName"`    // 被屏蔽用户名
+	CreatedTime time.Time `json:"createdTime"` // 屏蔽时间
+}
+
+type BlockUserReq struct {
+	UserId int64 `json:"userId" valid:"required"`
+}
+
+type GetBlocksReq struct {
+	CurrentPage int `json:"currentPage" valid:"required,min=1"`
+	PageSize    int `json:"pageSize" valid:"required,min=1,max=50"`
+}
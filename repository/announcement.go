@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+type AnnouncementRepository interface {
+	GetLogger() log.Logger
+	InsertAnnouncement(ctx context.Context, announcement *model.GroupAnnouncement) error
+	InsertAnnouncementAck(ctx context.Context, announcementId, userId int64) error
+	FindAnnouncement(ctx context.Context, announcement *model.GroupAnnouncement) error
+	FindPinnedAnnouncement(ctx context.Context, groupId, userId int64) (*model.GroupAnnouncement, error)
+	FindAnnouncements(ctx context.Context, groupId, userId int64, page *model.Page[*model.GroupAnnouncement]) error
+	UpdateAnnouncement(ctx context.Context, announcement *model.GroupAnnouncement) error
+	DeleteAnnouncement(ctx context.Context, announcementId int64) error
+}
+
+type announcementRepository struct {
+	db     DBTX
+	logger log.Logger
+}
+
+func NewAnnouncementRepository(db DBTX, logger log.Logger) AnnouncementRepository {
+	return &announcementRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *announcementRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 置顶公告时取消群内其他公告的置顶
+func (r *announcementRepository) unpinOthers(ctx context.Context, tx *sql.Tx, groupId, announcementId int64) error {
+	updateSql := `
+		update im_group_announcement
+		set
+			pinned = 0
+		where
+			group_id = ? and announcement_id != ? and pinned = 1
+	`
+	_, err := tx.ExecContext(
+		ctx,
+		updateSql,
+		groupId,
+		announcementId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return nil
+}
+
+func (r *announcementRepository) commit(tx *sql.Tx) error {
+	err := tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
+func (r *announcementRepository) InsertAnnouncement(ctx context.Context, announcement *model.GroupAnnouncement) error {
+	insertSql := `
+		insert into im_group_announcement(group_id,creator_id,title,content,pinned,need_ack)
+		values
+		(?,?,?,?,?,?)
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	result, err := tx.ExecContext(
+		ctx,
+		insertSql,
+		announcement.GroupId,
+		announcement.CreatorId,
+		announcement.Title,
+		announcement.Content,
+		announcement.Pinned,
+		announcement.NeedAck,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	announcement.AnnouncementId, err = result.LastInsertId()
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	if announcement.Pinned {
+		err = r.unpinOthers(ctx, tx, announcement.GroupId, announcement.AnnouncementId)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return r.commit(tx)
+}
+
+// 重复确认时保留第一次的确认时间
+func (r *announcementRepository) InsertAnnouncementAck(ctx context.Context, announcementId, userId int64) error {
+	insertSql := `
+		insert ignore into im_group_announcement_ack(announcement_id,user_id)
+		values
+		(?,?)
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		insertSql,
+		announcementId,
+		userId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	return nil
+}
+
+func (r *announcementRepository) FindAnnouncement(ctx context.Context, announcement *model.GroupAnnouncement) error {
+	selectSql := `
+		select group_id,creator_id,title,content,pinned,need_ack,unix_timestamp(created_time),unix_timestamp(updated_time)
+		from im_group_announcement
+		where
+			announcement_id = ? and deleted = ?
+	`
+	var createdTime, updatedTime int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		announcement.AnnouncementId,
+		0,
+	).Scan(
+		&announcement.GroupId,
+		&announcement.CreatorId,
+		&announcement.Title,
+		&announcement.Content,
+		&announcement.Pinned,
+		&announcement.NeedAck,
+		&createdTime,
+		&updatedTime,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	announcement.CreatedTime = time.Unix(createdTime, 0)
+	announcement.UpdatedTime = time.Unix(updatedTime, 0)
+	return nil
+}
+
+// 群当前的置顶公告，没有置顶公告时返回 nil
+func (r *announcementRepository) FindPinnedAnnouncement(ctx context.Context, groupId, userId int64) (*model.GroupAnnouncement, error) {
+	selectSql := `
+		select iga.announcement_id,iga.creator_id,iga.title,iga.content,iga.need_ack,unix_timestamp(iga.created_time),unix_timestamp(iga.updated_time),
+			(select count(*) from im_group_announcement_ack igaa where igaa.announcement_id = iga.announcement_id),
+			exists(select 1 from im_group_announcement_ack igaa where igaa.announcement_id = iga.announcement_id and igaa.user_id = ?)
+		from im_group_announcement iga
+		where
+			iga.group_id = ? and iga.pinned = ? and iga.deleted = ?
+		order by iga.announcement_id desc
+		limit 1
+	`
+	announcement := &model.GroupAnnouncement{
+		GroupId: groupId,
+		Pinned:  true,
+	}
+	var createdTime, updatedTime int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		userId,
+		groupId,
+		1,
+		0,
+	).Scan(
+		&announcement.AnnouncementId,
+		&announcement.CreatorId,
+		&announcement.Title,
+		&announcement.Content,
+		&announcement.NeedAck,
+		&createdTime,
+		&updatedTime,
+		&announcement.AckCount,
+		&announcement.Acked,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	announcement.CreatedTime = time.Unix(createdTime, 0)
+	announcement.UpdatedTime = time.Unix(updatedTime, 0)
+	return announcement, nil
+}
+
+// 置顶公告在前，其余按发布时间递减
+func (r *announcementRepository) FindAnnouncements(ctx context.Context, groupId, userId int64, page *model.Page[*model.GroupAnnouncement]) error {
+	selectSql := `
+		select iga.announcement_id,iga.creator_id,iga.title,iga.content,iga.pinned,iga.need_ack,unix_timestamp(iga.created_time),unix_timestamp(iga.updated_time),
+			(select count(*) from im_group_announcement_ack igaa where igaa.announcement_id = iga.announcement_id),
+			exists(select 1 from im_group_announcement_ack igaa where igaa.announcement_id = iga.announcement_id and igaa.user_id = ?)
+		from im_group_announcement iga
+		where
+			iga.group_id = ? and iga.deleted = ?
+		order by iga.pinned desc, iga.announcement_id desc
+		limit ? offset ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+		groupId,
+		0,
+		page.PageSize,
+		(page.CurrentPage-1)*page.PageSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		announcement := &model.GroupAnnouncement{
+			GroupId: groupId,
+		}
+		var createdTime, updatedTime int64
+		err = rows.Scan(
+			&announcement.AnnouncementId,
+			&announcement.CreatorId,
+			&announcement.Title,
+			&announcement.Content,
+			&announcement.Pinned,
+			&announcement.NeedAck,
+			&createdTime,
+			&updatedTime,
+			&announcement.AckCount,
+			&announcement.Acked,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		announcement.CreatedTime = time.Unix(createdTime, 0)
+		announcement.UpdatedTime = time.Unix(updatedTime, 0)
+		page.Items = append(page.Items, announcement)
+	}
+	page.Total = len(page.Items)
+	return nil
+}
+
+func (r *announcementRepository) UpdateAnnouncement(ctx context.Context, announcement *model.GroupAnnouncement) error {
+	updateSql := `
+		update im_group_announcement
+		set
+			title = ?,
+			content = ?,
+			pinned = ?,
+			need_ack = ?
+		where
+			announcement_id = ? and deleted = ?
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		updateSql,
+		announcement.Title,
+		announcement.Content,
+		announcement.Pinned,
+		announcement.NeedAck,
+		announcement.AnnouncementId,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	if announcement.Pinned {
+		err = r.unpinOthers(ctx, tx, announcement.GroupId, announcement.AnnouncementId)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return r.commit(tx)
+}
+
+func (r *announcementRepository) DeleteAnnouncement(ctx context.Context, announcementId int64) error {
+	updateSql := `
+		update im_group_announcement
+		set
+			pinned = 0,
+			deleted = 1
+		where
+			announcement_id = ? and deleted = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		announcementId,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlDeleteFail,
+			Message: constant.MsgSqlDeleteFail,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+type BlobRepository interface {
+	GetLogger() log.Logger
+	FindReadable(ctx context.Context, userId int64, blobId string) (bool, error)
+}
+
+type blobRepository struct {
+	db     DBTX
+	logger log.Logger
+}
+
+func NewBlobRepository(db DBTX, logger log.Logger) BlobRepository {
+	return &blobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *blobRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 用户能否读取附件: 用户头像与群头像公开可见，消息附件只对所在对话的成员可见，群消息附件只对发送时已在群内的成员可见
+func (r *blobRepository) FindReadable(ctx context.Context, userId int64, blobId string) (bool, error) {
+	selectSql := `
+		select
+			exists(
+				select 1 from im_users_detail
+				where
+					avatar = ?
+			)
+			or exists(
+				select 1 from im_groups_detail
+				where
+					group_avatar = ?
+			)
+			or exists(
+				select 1 from im_message
+				where
+					blob_id = ? and dialog_type = ? and deleted = ? and (sender = ? or receiver = ?)
+			)
+			or exists(
+				select 1 from im_message im
+				join im_groups_users igu on igu.group_id = im.receiver
+				where
+					im.blob_id = ? and im.dialog_type = ? and im.deleted = ?
+					and igu.user_id = ? and igu.deleted = ? and im.send_time >= igu.created_time
+			)
+	`
+	var readable bool
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		blobId,
+		blobId,
+		blobId,
+		DIALOG_SINGLE,
+		0,
+		userId,
+		userId,
+		blobId,
+		DIALOG_GROUP,
+		0,
+		userId,
+		0,
+	).Scan(&readable)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return readable, nil
+}
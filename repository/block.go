@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+type BlockRepository interface {
+	GetLogger() log.Logger
+	InsertBlock(ctx context.Context, blockerId, blockedId int64) error
+	DeleteBlock(ctx context.Context, blockerId, blockedId int64) error
+	FindBlocked(ctx context.Context, blockerId, blockedId int64) (bool, error)
+	FindBlocks(ctx context.Context, blockerId int64, page *model.Page[*model.UserBlock]) error
+}
+
+type blockRepository struct {
+	db     DBTX
+	logger log.Logger
+}
+
+func NewBlockRepository(db DBTX, logger log.Logger) BlockRepository {
+	return &blockRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *blockRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 重复屏蔽时保留第一次的屏蔽时间
+func (r *blockRepository) InsertBlock(ctx context.Context, blockerId, blockedId int64) error {
+	insertSql := `
+		insert ignore into im_user_block(blocker_id,blocked_id)
+		values
+		(?,?)
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		insertSql,
+		blockerId,
+		blockedId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	return nil
+}
+
+func (r *blockRepository) DeleteBlock(ctx context.Context, blockerId, blockedId int64) error {
+	deleteSql := `
+		delete from im_user_block
+		where
+			blocker_id = ? and blocked_id = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		deleteSql,
+		blockerId,
+		blockedId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			deleteSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlDeleteFail,
+			Message: constant.MsgSqlDeleteFail,
+		}
+	}
+	return nil
+}
+
+// blockerId 是否屏蔽了 blockedId
+func (r *blockRepository) FindBlocked(ctx context.Context, blockerId, blockedId int64) (bool, error) {
+	selectSql := `
+		select exists(
+			select 1 from im_user_block
+			where
+				blocker_id = ? and blocked_id = ?
+		)
+	`
+	var blocked bool
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		blockerId,
+		blockedId,
+	).Scan(&blocked)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return blocked, nil
+}
+
+// 按屏蔽时间递减分页列出屏蔽的用户
+func (r *blockRepository) FindBlocks(ctx context.Context, blockerId int64, page *model.Page[*model.UserBlock]) error {
+	selectSql := `
+		select iub.blocked_id,coalesce(iu.user_name,''),unix_timestamp(iub.created_time)
+		from im_user_block iub
+		left join im_users iu
+		on iub.blocked_id = iu.user_id
+		where
+			iub.blocker_id = ?
+		order by iub.created_time desc, iub.blocked_id
+		limit ? offset ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		blockerId,
+		page.PageSize,
+		(page.CurrentPage-1)*page.PageSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		block := &model.UserBlock{}
+		var createdTime int64
+		err = rows.Scan(
+			&block.UserId,
+			&block.UserName,
+			&createdTime,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		block.CreatedTime = time.Unix(createdTime, 0)
+		page.Items = append(page.Items, block)
+	}
+	page.Total = len(page.Items)
+	return nil
+}
@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+const (
+	UNREAD_KEY_PREFIX       = "unread:"
+	CONVERSATION_KEY_PREFIX = "conversation:"
+	CONVERSATION_PIN_SUFFIX = ":pinned"
+)
+
+// 对话类型，对应 im_dialog
+const (
+	DIALOG_SINGLE = 1
+	DIALOG_GROUP  = 2
+)
+
+type ConversationRepository interface {
+	GetLogger() log.Logger
+	FindSingleConversations(ctx context.Context, userId int64, status int, tagId int64) ([]*model.Conversation, error)
+	FindGroupConversations(ctx context.Context, userId int64, tagId int64) ([]*model.Conversation, error)
+	FindUnread(ctx context.Context, userId int64, conversations []*model.Conversation) error
+	FindPinned(ctx context.Context, userId int64, conversations []*model.Conversation) error
+	IncrUnread(ctx context.Context, userIds []int64, dialogType int, dialogId int64) error
+	DecrUnread(ctx context.Context, userId int64, dialogType int, dialogId int64, n int64) error
+	UpdatePinned(ctx context.Context, userId int64, dialogType int, dialogId int64, pinned bool) error
+}
+
+// 未读数减少 n，减到 0 及以下时删除字段
+var decrUnreadScript = redis.NewScript(`
+local count = redis.call('HINCRBY', KEYS[1], ARGV[1], -tonumber(ARGV[2]))
+if count <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// 会话成员关系与最后一条消息来自 mysql
+// 未读数保存在 redis 哈希 unread:<userId>，置顶保存在集合 conversation:<userId>:pinned，字段均为 <dialogType>:<dialogId>
+type conversationRepository struct {
+	db     DBTX
+	rdb    *redis.Client
+	logger log.Logger
+}
+
+func NewConversationRepository(db DBTX, rdb *redis.Client, logger log.Logger) ConversationRepository {
+	return &conversationRepository{
+		db:     db,
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+func (r *conversationRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+func (r *conversationRepository) unreadKey(userId int64) string {
+	return UNREAD_KEY_PREFIX + strconv.FormatInt(userId, 10)
+}
+
+func (r *conversationRepository) pinKey(userId int64) string {
+	return CONVERSATION_KEY_PREFIX + strconv.FormatInt(userId, 10) + CONVERSATION_PIN_SUFFIX
+}
+
+func (r *conversationRepository) field(dialogType int, dialogId int64) string {
+	return strconv.Itoa(dialogType) + ":" + strconv.FormatInt(dialogId, 10)
+}
+
+// 已建立的单聊及用户收件箱中与对方之间的最后一条消息，tagId 不为 0 时只返回关联了该用户此标签的单聊
+func (r *conversationRepository) FindSingleConversations(ctx context.Context, userId int64, status int, tagId int64) ([]*model.Conversation, error) {
+	selectSql := `
+		select c.single_id,c.peer_id,(case when c.remark = '' then iu.user_name else c.remark end),coalesce(iud.avatar,''),c.disturb,unix_timestamp(c.created_time),
+			coalesce(im.message_id,0),coalesce(im.sender,0),coalesce(im.receiver,0),coalesce(imt.type_name,''),coalesce(im.content,''),coalesce(ims.status_name,''),coalesce(unix_timestamp(im.send_time),0)
+		from (
+			select single_id,invitee_id as peer_id,invitee_nickname as remark,inviter_disturb as disturb,created_time
+			from im_single_chat
+			where inviter_id = ? and status = ? and deleted = ?
+			union all
+			select single_id,inviter_id,inviter_nickname,invitee_disturb,created_time
+			from im_single_chat
+			where invitee_id = ? and status = ? and deleted = ?
+		) c
+		join im_users iu on iu.user_id = c.peer_id and iu.deleted = ?
+		left join im_users_detail iud on iud.user_id = c.peer_id
+		left join im_message im on im.message_id = (
+			select max(it.message_id) from im_timeline it
+			join im_message m on m.message_id = it.message_id
+			where it.timeline_type = ? and it.timeline_id = ? and it.dialog_type = ? and it.deleted = ?
+				and (m.sender = c.peer_id or m.receiver = c.peer_id) and m.deleted = ?
+		)
+		left join im_message_type imt on im.type = imt.type_id
+		left join im_message_status ims on im.status = ims.status_id
+		where
+			? = 0 or exists (
+				select 1 from im_tag_item iti
+				join im_tag it on it.tag_id = iti.tag_id
+				where iti.tag_id = ? and it.user_id = ? and iti.dialog_type = ? and iti.dialog_id = c.single_id
+			)
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+		status,
+		0,
+		userId,
+		status,
+		0,
+		0,
+		TIMELINE_USER,
+		userId,
+		DIALOG_SINGLE,
+		0,
+		0,
+		tagId,
+		tagId,
+		userId,
+		DIALOG_SINGLE,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	conversations := make([]*model.Conversation, 0)
+	for rows.Next() {
+		conversation := &model.Conversation{
+			DialogType: DIALOG_SINGLE,
+		}
+		err = r.scan(rows, conversation, &conversation.SingleId)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		conversations = append(conversations, conversation)
+	}
+	return conversations, nil
+}
+
+// 用户所在的群及用户能同步到的最后一条群消息: 写扩散的消息在用户收件箱，读扩散的消息在群时间线且只取入群之后写入的
+// tagId 不为 0 时只返回关联了该用户此标签的群
+func (r *conversationRepository) FindGroupConversations(ctx context.Context, userId int64, tagId int64) ([]*model.Conversation, error) {
+	selectSql := `
+		select igu.group_id,(case when coalesce(igu.group_nickname,'') = '' then ig.group_name else igu.group_nickname end),coalesce(igd.group_avatar,''),igu.disturb,unix_timestamp(igu.created_time),
+			coalesce(im.message_id,0),coalesce(im.sender,0),coalesce(im.receiver,0),coalesce(imt.type_name,''),coalesce(im.content,''),coalesce(ims.status_name,''),coalesce(unix_timestamp(im.send_time),0)
+		from im_groups_users igu
+		join im_groups ig on ig.group_id = igu.group_id and ig.deleted = ?
+		left join im_groups_detail igd on igd.group_id = igu.group_id
+		left join im_message im on im.message_id = greatest(
+			coalesce((
+				select max(it.message_id) from im_timeline it
+				join im_message m on m.message_id = it.message_id
+				where it.timeline_type = ? and it.timeline_id = igu.user_id and it.dialog_type = ? and it.deleted = ?
+					and m.receiver = igu.group_id and m.deleted = ?
+			), 0),
+			coalesce((
+				select max(it.message_id) from im_timeline it
+				join im_message m on m.message_id = it.message_id
+				where it.timeline_type = ? and it.timeline_id = igu.group_id and it.deleted = ?
+					and it.created_time >= igu.created_time and m.deleted = ?
+			), 0)
+		)
+		left join im_message_type imt on im.type = imt.type_id
+		left join im_message_status ims on im.status = ims.status_id
+		where
+			igu.user_id = ? and igu.deleted = ?
+			and (? = 0 or exists (
+				select 1 from im_tag_item iti
+				join im_tag it on it.tag_id = iti.tag_id
+				where iti.tag_id = ? and it.user_id = ? and iti.dialog_type = ? and iti.dialog_id = igu.group_id
+			))
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		0,
+		TIMELINE_USER,
+		DIALOG_GROUP,
+		0,
+		0,
+		TIMELINE_GROUP,
+		0,
+		0,
+		userId,
+		0,
+		tagId,
+		tagId,
+		userId,
+		DIALOG_GROUP,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	conversations := make([]*model.Conversation, 0)
+	for rows.Next() {
+		conversation := &model.Conversation{
+			DialogType: DIALOG_GROUP,
+		}
+		err = r.scan(rows, conversation)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		conversations = append(conversations, conversation)
+	}
+	return conversations, nil
+}
+
+// 读取会话的公共列与最后一条消息，prefix 为公共列之前的额外列
+// 没有消息时最后活跃时间取建立会话的时间
+func (r *conversationRepository) scan(rows *sql.Rows, conversation *model.Conversation, prefix ...any) error {
+	var createdTime, sendTime int64
+	message := &model.MessageItem{
+		DialogType: conversation.DialogType,
+	}
+	dest := append(prefix,
+		&conversation.DialogId,
+		&conversation.Name,
+		&conversation.Avatar,
+		&conversation.Disturb,
+		&createdTime,
+		&message.MessageId,
+		&message.Sender,
+		&message.Receiver,
+		&message.Type,
+		&message.Text,
+		&message.Status,
+		&sendTime,
+	)
+	err := rows.Scan(dest...)
+	if err != nil {
+		return err
+	}
+	conversation.LastTime = time.Unix(createdTime, 0)
+	if message.MessageId > 0 {
+		message.SendTime = time.Unix(sendTime, 0)
+		conversation.LastMessage = message
+		conversation.LastTime = message.SendTime
+	}
+	return nil
+}
+
+// 按会话逐个读取未读数，没有记录的为 0
+func (r *conversationRepository) FindUnread(ctx context.Context, userId int64, conversations []*model.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	fields := make([]string, 0, len(conversations))
+	for _, conversation := range conversations {
+		fields = append(fields, r.field(conversation.DialogType, conversation.DialogId))
+	}
+	values, err := r.rdb.HMGet(ctx, r.unreadKey(userId), fields...).Result()
+	if err != nil {
+		log.Warn(
+			r.logger,
+			"redis unread select",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			conversations[i].UnreadCount, _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return nil
+}
+
+func (r *conversationRepository) FindPinned(ctx context.Context, userId int64, conversations []*model.Conversation) error {
+	members, err := r.rdb.SMembers(ctx, r.pinKey(userId)).Result()
+	if err != nil {
+		log.Warn(
+			r.logger,
+			"redis pinned select",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	pinned := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		pinned[member] = struct{}{}
+	}
+	for _, conversation := range conversations {
+		_, conversation.Pinned = pinned[r.field(conversation.DialogType, conversation.DialogId)]
+	}
+	return nil
+}
+
+// 对话中每个接收者的未读数加一
+func (r *conversationRepository) IncrUnread(ctx context.Context, userIds []int64, dialogType int, dialogId int64) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	field := r.field(dialogType, dialogId)
+	pipe := r.rdb.Pipeline()
+	for _, userId := range userIds {
+		pipe.HIncrBy(ctx, r.unreadKey(userId), field, 1)
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		log.Warn(
+			r.logger,
+			"redis unread incr",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 未读数扣除新读到的消息数，不会小于 0
+func (r *conversationRepository) DecrUnread(ctx context.Context, userId int64, dialogType int, dialogId int64, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	err := decrUnreadScript.Run(ctx, r.rdb, []string{r.unreadKey(userId)}, r.field(dialogType, dialogId), n).Err()
+	if err != nil {
+		log.Warn(
+			r.logger,
+			"redis unread decr",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+func (r *conversationRepository) UpdatePinned(ctx context.Context, userId int64, dialogType int, dialogId int64, pinned bool) error {
+	var err error
+	if pinned {
+		err = r.rdb.SAdd(ctx, r.pinKey(userId), r.field(dialogType, dialogId)).Err()
+	} else {
+		err = r.rdb.SRem(ctx, r.pinKey(userId), r.field(dialogType, dialogId)).Err()
+	}
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis pinned update",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
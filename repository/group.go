@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
@@ -12,18 +15,32 @@ type GroupRepository interface {
 	GetLogger() log.Logger
 	InsertOneGroup(ctx context.Context, group *model.Group) (int64, error)
 	InsertUserInGroup(ctx context.Context, groupToUser *model.GroupToUser) error
+	UpsertUserInGroup(ctx context.Context, groupToUser *model.GroupToUser) error
+	UpsertGroupBan(ctx context.Context, groupBan *model.GroupBan) error
 	FindGroupToUser(ctx context.Context, groupToUser *model.GroupToUser) error
 	FindGroup(ctx context.Context, group *model.Group) error
 	FindGroupPassword(ctx context.Context, groupId int64, groupPassword *string) error
+	FindGroupJoinPolicy(ctx context.Context, groupId int64) (string, error)
+	FindGroupBans(ctx context.Context, groupId int64, page *model.Page[*model.GroupBan]) error
+	FindGroupBanned(ctx context.Context, groupId, userId int64) (bool, error)
 	FindUserRoleId(ctx context.Context, userRole string) (int, error)
 	FindGroupBasic(ctx context.Context, groupBasic *model.GroupBasic) error
 	FindGroupUsers(ctx context.Context, groupUser *model.GroupUser, page *model.Page[*model.GroupToUserItem]) error
 	FindGroups(ctx context.Context, groupItem *model.GroupItem, page *model.Page[*model.GroupItem]) error
 	FindGroupAllUsers(ctx context.Context, groupId int64, page *model.Page[*model.GroupToUserItem]) error
+	FindGroupMemberIds(ctx context.Context, groupId int64) ([]int64, error)
+	FindUserGroups(ctx context.Context, userId int64) ([]*model.Group, error)
+	FindGroupSuccessor(ctx context.Context, groupId, ownerId int64, adminRoleId int) (int64, error)
+	FindOwnedGroupIds(ctx context.Context, userId int64, ownerRoleId int) ([]int64, error)
+	UpdateGroupOwner(ctx context.Context, groupId, ownerId, successorId int64, ownerRoleId, demoteRoleId int) error
 	UpdateGroup(ctx context.Context, group *model.Group) error
+	UpdateGroupJoinPolicy(ctx context.Context, groupId int64, joinPolicy string) error
+	UpdateGroupPassword(ctx context.Context, groupId int64, oldPassword, newPassword string) error
 	UpdateGroupToUser(ctx context.Context, groupToUser *model.GroupToUser) error
+	UpdateGroupUserMute(ctx context.Context, groupId, userId int64, muteUntil time.Time) error
 	DeleteGroup(ctx context.Context, groupId int64) error
 	DeleteGroupToUser(ctx context.Context, groupId, userId int64) error
+	DeleteGroupBan(ctx context.Context, groupId, userId int64) error
 }
 
 type groupRepository struct {
@@ -245,6 +262,206 @@ func (r *groupRepository) InsertUserInGroup(ctx context.Context, groupToUser *mo
 	return nil
 }
 
+// 加群: 锁定群容量后写入成员关系，已退出的成员恢复原有记录
+func (r *groupRepository) UpsertUserInGroup(ctx context.Context, groupToUser *model.GroupToUser) error {
+	selectSql := `
+		select igd.max_size,igd.current_size
+		from im_groups ig
+		join im_groups_detail igd
+		on ig.group_id = igd.group_id
+		where
+			ig.group_id = ? and ig.deleted = ?
+		for update
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	var maxSize, currentSize int
+	err = tx.QueryRowContext(
+		ctx,
+		selectSql,
+		groupToUser.GroupId,
+		0,
+	).Scan(
+		&maxSize,
+		&currentSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	if currentSize >= maxSize {
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrGroupFull,
+			Message: constant.MsgGroupFull,
+		}
+	}
+	selectSql = `
+		select count(*)
+		from im_group_ban
+		where
+			group_id = ? and user_id = ? and (expire_time is null or expire_time > current_timestamp)
+	`
+	var banned int
+	err = tx.QueryRowContext(
+		ctx,
+		selectSql,
+		groupToUser.GroupId,
+		groupToUser.UserId,
+	).Scan(&banned)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	if banned > 0 {
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrGroupBanned,
+			Message: constant.MsgGroupBanned,
+		}
+	}
+	selectSql = `
+		select deleted
+		from im_groups_users
+		where
+			group_id = ? and user_id = ?
+		for update
+	`
+	var deleted int
+	err = tx.QueryRowContext(
+		ctx,
+		selectSql,
+		groupToUser.GroupId,
+		groupToUser.UserId,
+	).Scan(&deleted)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	if err == nil && deleted == 0 {
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrGroupAlreadyMember,
+			Message: constant.MsgGroupAlreadyMember,
+		}
+	}
+	upsertSql := `
+		insert into im_groups_users(group_id,user_id,group_nickname,user_nickname,user_role,user_role_nickname,disturb)
+		values
+		(?,?,?,?,?,?,?)
+		on duplicate key update
+			group_nickname = values(group_nickname),
+			user_nickname = values(user_nickname),
+			user_role = values(user_role),
+			user_role_nickname = values(user_role_nickname),
+			disturb = values(disturb),
+			created_time = current_timestamp,
+			deleted = 0
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		upsertSql,
+		groupToUser.GroupId,
+		groupToUser.UserId,
+		groupToUser.GroupNickname,
+		groupToUser.UserNickname,
+		groupToUser.UserRoleId,
+		groupToUser.UserRoleNickname,
+		groupToUser.UserDisturb,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			upsertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	updateSql := `
+		update im_groups_detail
+		set
+			current_size = current_size+1
+		where
+			group_id = ?
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		updateSql,
+		groupToUser.GroupId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
 func (r *groupRepository) FindUserRoleId(ctx context.Context, userRole string) (int, error) {
 	selectSql := `
 		select role_id from im_users_role
@@ -310,11 +527,14 @@ func (r *groupRepository) FindGroupToUser(ctx context.Context, groupToUser *mode
 		}
 	}
 	selectSql = `
-		select group_nickname,user_nickname,user_role,user_role_nickname,disturb
-		from im_groups_users
+		select igu.group_nickname,igu.user_nickname,igu.user_role,iur.role_name,igu.user_role_nickname,igu.disturb,coalesce(unix_timestamp(igu.mute_until),0)
+		from im_groups_users igu
+		left join im_users_role iur
+		on igu.user_role = iur.role_id
 		where 
-			group_id = ? and deleted = ? and user_id = ?
+			igu.group_id = ? and igu.deleted = ? and igu.user_id = ?
 	`
+	var muteUntil int64
 	err = r.db.QueryRowContext(
 		ctx,
 		selectSql,
@@ -325,8 +545,10 @@ func (r *groupRepository) FindGroupToUser(ctx context.Context, groupToUser *mode
 		&groupToUser.GroupNickname,
 		&groupToUser.UserNickname,
 		&groupToUser.UserRoleId,
+		&groupToUser.UserRole,
 		&groupToUser.UserRoleNickname,
 		&groupToUser.UserDisturb,
+		&muteUntil,
 	)
 	if err != nil {
 		log.Error(
@@ -341,6 +563,9 @@ func (r *groupRepository) FindGroupToUser(ctx context.Context, groupToUser *mode
 			Message: constant.MsgSqlSelectFail,
 		}
 	}
+	if muteUntil > time.Now().Unix() {
+		groupToUser.MuteUntil = time.Unix(muteUntil, 0)
+	}
 	return nil
 }
 
@@ -517,7 +742,7 @@ func (r *groupRepository) FindGroupUsers(ctx context.Context, groupUser *model.G
 
 func (r *groupRepository) FindGroupAllUsers(ctx context.Context, groupId int64, page *model.Page[*model.GroupToUserItem]) error {
 	selectSql := `
-		select igu.user_id,iu.user_name,igu.user_nickname,iur.role_name,igu.user_role_nickname
+		select igu.user_id,iu.user_name,igu.user_nickname,iur.role_name,igu.user_role_nickname,coalesce(unix_timestamp(igu.mute_until),0)
 		from im_groups_users igu
 		left join im_users iu on iu.user_id = igu.user_id
 		left join im_users_role iur on igu.user_role = iur.role_id
@@ -552,12 +777,14 @@ func (r *groupRepository) FindGroupAllUsers(ctx context.Context, groupId int64,
 	defer rows.Close()
 	for rows.Next() {
 		var groupItem model.GroupToUserItem
+		var muteUntil int64
 		err := rows.Scan(
 			&groupItem.UserId,
 			&groupItem.UserName,
 			&groupItem.UserNickname,
 			&groupItem.UserRole,
 			&groupItem.UserRoleNickname,
+			&muteUntil,
 		)
 		if err != nil {
 			log.Error(
@@ -572,12 +799,125 @@ func (r *groupRepository) FindGroupAllUsers(ctx context.Context, groupId int64,
 				Message: constant.MsgSqlSelectFail,
 			}
 		}
+		if muteUntil > time.Now().Unix() {
+			groupItem.MuteUntil = time.Unix(muteUntil, 0)
+		}
 		page.Items = append(page.Items, &groupItem)
 	}
 	page.Total = len(page.Items)
 	return nil
 }
 
+// 查找群内所有成员账号，用于消息扩散与推送
+func (r *groupRepository) FindGroupMemberIds(ctx context.Context, groupId int64) ([]int64, error) {
+	selectSql := `
+		select user_id
+		from im_groups_users
+		where
+			group_id = ? and deleted = ?
+	`
+	var userIds []int64
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		groupId,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userId int64
+		err = rows.Scan(&userId)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		userIds = append(userIds, userId)
+	}
+	return userIds, nil
+}
+
+// 查找用户加入的所有群及其当前人数
+func (r *groupRepository) FindUserGroups(ctx context.Context, userId int64) ([]*model.Group, error) {
+	selectSql := `
+		select ig.group_id,ig.group_name,igd.group_avatar,igd.max_size,igd.current_size
+		from im_groups_users igu
+		join im_groups ig on ig.group_id = igu.group_id
+		left join im_groups_detail igd on ig.group_id = igd.group_id
+		where
+			igu.user_id = ? and igu.deleted = ? and ig.deleted = ?
+		order by ig.group_id
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+		0,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	var groups []*model.Group
+	for rows.Next() {
+		var group model.Group
+		err = rows.Scan(
+			&group.GroupId,
+			&group.GroupName,
+			&group.GroupAvatar,
+			&group.GroupMaxSize,
+			&group.GroupCurrentSize,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		groups = append(groups, &group)
+	}
+	return groups, nil
+}
+
 func (r *groupRepository) FindGroups(ctx context.Context, groupItem *model.GroupItem, page *model.Page[*model.GroupItem]) error {
 	selectSql := `
 		select group_id,group_name
@@ -634,16 +974,566 @@ func (r *groupRepository) FindGroups(ctx context.Context, groupItem *model.Group
 	return nil
 }
 
-func (r *groupRepository) UpdateGroup(ctx context.Context, group *model.Group) error {
-	updateSql := `
-		update im_groups as ig join im_groups_detail as igd
-		on ig.group_id = igd.group_id
-		set
-			ig.group_name = ?,
-			ig.group_password = ?,
-			igd.group_avatar = ?,
-			igd.max_size = ?
-		where
+// 按入群时间查找继任者: 优先管理员，其次普通成员，没有其他成员时返回 0
+func (r *groupRepository) FindGroupSuccessor(ctx context.Context, groupId, ownerId int64, adminRoleId int) (int64, error) {
+	selectSql := `
+		select user_id
+		from im_groups_users
+		where
+			group_id = ? and deleted = ? and user_id <> ?
+		order by user_role = ? desc, created_time asc, user_id asc
+		limit 1
+	`
+	var successorId int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		groupId,
+		0,
+		ownerId,
+		adminRoleId,
+	).Scan(&successorId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return 0, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return successorId, nil
+}
+
+// 查找用户作为群主的所有群
+func (r *groupRepository) FindOwnedGroupIds(ctx context.Context, userId int64, ownerRoleId int) ([]int64, error) {
+	selectSql := `
+		select igu.group_id
+		from im_groups_users igu
+		join im_groups ig
+		on igu.group_id = ig.group_id
+		where
+			igu.user_id = ? and igu.deleted = ? and igu.user_role = ? and ig.deleted = ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+		0,
+		ownerRoleId,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	var groupIds []int64
+	for rows.Next() {
+		var groupId int64
+		err = rows.Scan(&groupId)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		groupIds = append(groupIds, groupId)
+	}
+	return groupIds, nil
+}
+
+// 转让群主: 在同一事务中降级原群主并提升继任者
+func (r *groupRepository) UpdateGroupOwner(ctx context.Context, groupId, ownerId, successorId int64, ownerRoleId, demoteRoleId int) error {
+	selectSql := `
+		select user_id,user_role
+		from im_groups_users
+		where
+			group_id = ? and deleted = ? and user_id in (?,?)
+		for update
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	rows, err := tx.QueryContext(
+		ctx,
+		selectSql,
+		groupId,
+		0,
+		ownerId,
+		successorId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	roles := make(map[int64]int, 2)
+	for rows.Next() {
+		var userId int64
+		var userRole int
+		err = rows.Scan(&userId, &userRole)
+		if err != nil {
+			break
+		}
+		roles[userId] = userRole
+	}
+	rows.Close()
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	// 加锁后再次确认双方身份，避免并发转让
+	successorRole, ok := roles[successorId]
+	if roles[ownerId] != ownerRoleId || !ok || successorRole == ownerRoleId {
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	updateSql := `
+		update im_groups_users
+		set
+			user_role = ?,
+			user_role_nickname = ?
+		where
+			group_id = ? and deleted = ? and user_id = ?
+	`
+	changes := []struct {
+		userId int64
+		roleId int
+	}{
+		{ownerId, demoteRoleId},
+		{successorId, ownerRoleId},
+	}
+	for _, change := range changes {
+		_, err = tx.ExecContext(
+			ctx,
+			updateSql,
+			change.roleId,
+			"",
+			groupId,
+			0,
+			change.userId,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				updateSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			tx.Rollback()
+			return &model.DError{
+				Code:    constant.ErrSqlUpdateFail,
+				Message: constant.MsgSqlUpdateFail,
+			}
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
+func (r *groupRepository) FindGroupJoinPolicy(ctx context.Context, groupId int64) (string, error) {
+	selectSql := `
+		select igd.join_policy
+		from im_groups ig
+		join im_groups_detail igd
+		on ig.group_id = igd.group_id
+		where
+			ig.group_id = ? and ig.deleted = ?
+	`
+	var joinPolicy string
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		groupId,
+		0,
+	).Scan(&joinPolicy)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return "", &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return joinPolicy, nil
+}
+
+func (r *groupRepository) UpdateGroupJoinPolicy(ctx context.Context, groupId int64, joinPolicy string) error {
+	updateSql := `
+		update im_groups_detail
+		set
+			join_policy = ?
+		where
+			group_id = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		joinPolicy,
+		groupId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return nil
+}
+
+// 仅在群密码仍为 oldPassword 时替换，避免覆盖并发修改的密码
+func (r *groupRepository) UpdateGroupPassword(ctx context.Context, groupId int64, oldPassword, newPassword string) error {
+	updateSql := `
+		update im_groups
+		set
+			group_password = ?
+		where
+			group_id = ? and group_password = ? and deleted = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		newPassword,
+		groupId,
+		oldPassword,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return nil
+}
+
+// 封禁用户并在同一事务中将其移出群，重复封禁时覆盖原有的封禁信息
+func (r *groupRepository) UpsertGroupBan(ctx context.Context, groupBan *model.GroupBan) error {
+	upsertSql := `
+		insert into im_group_ban(group_id,user_id,operator_id,reason,expire_time)
+		values
+		(?,?,?,?,from_unixtime(?))
+		on duplicate key update
+			operator_id = values(operator_id),
+			reason = values(reason),
+			expire_time = values(expire_time),
+			created_time = current_timestamp
+	`
+	var expireTime sql.NullInt64
+	if !groupBan.ExpireTime.IsZero() {
+		expireTime = sql.NullInt64{Int64: groupBan.ExpireTime.Unix(), Valid: true}
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		upsertSql,
+		groupBan.GroupId,
+		groupBan.UserId,
+		groupBan.OperatorId,
+		groupBan.Reason,
+		expireTime,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			upsertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	_, err = r.removeGroupUser(ctx, tx, groupBan.GroupId, groupBan.UserId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
+// 群内仍然生效的封禁，按封禁时间递减
+func (r *groupRepository) FindGroupBans(ctx context.Context, groupId int64, page *model.Page[*model.GroupBan]) error {
+	selectSql := `
+		select igb.user_id,iu.user_name,igb.operator_id,igb.reason,coalesce(unix_timestamp(igb.expire_time),0),unix_timestamp(igb.created_time)
+		from im_group_ban igb
+		left join im_users iu on iu.user_id = igb.user_id
+		where
+			igb.group_id = ? and (igb.expire_time is null or igb.expire_time > current_timestamp)
+		order by igb.created_time desc, igb.user_id
+		limit ? offset ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		groupId,
+		page.PageSize,
+		(page.CurrentPage-1)*page.PageSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		groupBan := &model.GroupBan{
+			GroupId: groupId,
+		}
+		var expireTime, createdTime int64
+		err = rows.Scan(
+			&groupBan.UserId,
+			&groupBan.UserName,
+			&groupBan.OperatorId,
+			&groupBan.Reason,
+			&expireTime,
+			&createdTime,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		if expireTime > 0 {
+			groupBan.ExpireTime = time.Unix(expireTime, 0)
+		}
+		groupBan.CreatedTime = time.Unix(createdTime, 0)
+		page.Items = append(page.Items, groupBan)
+	}
+	page.Total = len(page.Items)
+	return nil
+}
+
+// 用户在群内是否处于封禁期
+func (r *groupRepository) FindGroupBanned(ctx context.Context, groupId, userId int64) (bool, error) {
+	selectSql := `
+		select count(*)
+		from im_group_ban
+		where
+			group_id = ? and user_id = ? and (expire_time is null or expire_time > current_timestamp)
+	`
+	var count int
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		groupId,
+		userId,
+	).Scan(&count)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return count > 0, nil
+}
+
+func (r *groupRepository) DeleteGroupBan(ctx context.Context, groupId, userId int64) error {
+	deleteSql := `
+		delete from im_group_ban
+		where
+			group_id = ? and user_id = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		deleteSql,
+		groupId,
+		userId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			deleteSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlDeleteFail,
+			Message: constant.MsgSqlDeleteFail,
+		}
+	}
+	return nil
+}
+
+// 设置成员禁言截止时间，零值表示解除禁言
+func (r *groupRepository) UpdateGroupUserMute(ctx context.Context, groupId, userId int64, muteUntil time.Time) error {
+	updateSql := `
+		update im_groups_users
+		set
+			mute_until = from_unixtime(?)
+		where
+			group_id = ? and user_id = ? and deleted = ?
+	`
+	var mute sql.NullInt64
+	if !muteUntil.IsZero() {
+		mute = sql.NullInt64{Int64: muteUntil.Unix(), Valid: true}
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		mute,
+		groupId,
+		userId,
+		0,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return nil
+}
+
+func (r *groupRepository) UpdateGroup(ctx context.Context, group *model.Group) error {
+	updateSql := `
+		update im_groups as ig join im_groups_detail as igd
+		on ig.group_id = igd.group_id
+		set
+			ig.group_name = ?,
+			ig.group_password = ?,
+			igd.group_avatar = ?,
+			igd.max_size = ?
+		where
 			ig.group_id = ? and ig.deleted = ?
 	`
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -824,14 +1714,29 @@ func (r *groupRepository) DeleteGroup(ctx context.Context, groupId int64) error
 		}
 	}
 	rowChange, err := result.RowsAffected()
-	if err != nil || rowChange != 1 {
+	if err != nil {
 		log.Error(
 			r.logger,
-			"nothing to delete",
+			deleteSql,
 			map[string]any{
 				"error": err.Error(),
 			},
 		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlDeleteFail,
+			Message: constant.MsgSqlDeleteFail,
+		}
+	}
+	if rowChange != 1 {
+		log.Error(
+			r.logger,
+			"nothing to delete",
+			map[string]any{
+				"group_id": groupId,
+			},
+		)
+		tx.Rollback()
 		return &model.DError{
 			Code:    constant.ErrSqlDeleteFail,
 			Message: constant.MsgSqlDeleteFail,
@@ -856,13 +1761,6 @@ func (r *groupRepository) DeleteGroup(ctx context.Context, groupId int64) error
 }
 
 func (r *groupRepository) DeleteGroupToUser(ctx context.Context, groupId, userId int64) error {
-	deleteSql := `
-		update im_groups_users
-		set
-			deleted = ?
-		where
-			group_id = ? and deleted = ? and user_id = ?
-	`
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return &model.DError{
@@ -870,6 +1768,53 @@ func (r *groupRepository) DeleteGroupToUser(ctx context.Context, groupId, userId
 			Message: constant.MsgTransactionBegin,
 		}
 	}
+	removed, err := r.removeGroupUser(ctx, tx, groupId, userId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !removed {
+		log.Error(
+			r.logger,
+			"nothing to delete",
+			map[string]any{
+				"group_id": groupId,
+				"user_id":  userId,
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlDeleteFail,
+			Message: constant.MsgSqlDeleteFail,
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
+// 在事务中移出成员并减少群人数，成员已不在群内时不做修改
+func (r *groupRepository) removeGroupUser(ctx context.Context, tx *sql.Tx, groupId, userId int64) (bool, error) {
+	deleteSql := `
+		update im_groups_users
+		set
+			deleted = ?
+		where
+			group_id = ? and deleted = ? and user_id = ?
+	`
 	result, err := tx.ExecContext(
 		ctx,
 		deleteSql,
@@ -886,40 +1831,52 @@ func (r *groupRepository) DeleteGroupToUser(ctx context.Context, groupId, userId
 				"error": err.Error(),
 			},
 		)
-		tx.Rollback()
-		return &model.DError{
+		return false, &model.DError{
 			Code:    constant.ErrSqlDeleteFail,
 			Message: constant.MsgSqlDeleteFail,
 		}
 	}
 	rowChange, err := result.RowsAffected()
-	if err != nil || rowChange != 1 {
+	if err != nil {
 		log.Error(
 			r.logger,
-			"nothing to delete",
+			deleteSql,
 			map[string]any{
 				"error": err.Error(),
 			},
 		)
-		return &model.DError{
+		return false, &model.DError{
 			Code:    constant.ErrSqlDeleteFail,
 			Message: constant.MsgSqlDeleteFail,
 		}
 	}
-	err = tx.Commit()
+	if rowChange == 0 {
+		return false, nil
+	}
+	updateSql := `
+		update im_groups_detail
+		set
+			current_size = current_size-1
+		where
+			group_id = ? and current_size > 0
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		updateSql,
+		groupId,
+	)
 	if err != nil {
-		tx.Rollback()
 		log.Error(
 			r.logger,
-			"transaction commit fail",
+			updateSql,
 			map[string]any{
 				"error": err.Error(),
 			},
 		)
-		return &model.DError{
-			Code:    constant.ErrTransactionFail,
-			Message: constant.MsgTransactionFail,
+		return false, &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgOperationFail,
 		}
 	}
-	return nil
+	return true, nil
 }
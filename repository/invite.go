@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+const (
+	INVITE_KEY_PREFIX       = "invite:"
+	GROUP_INVITE_KEY_PREFIX = "group:"
+	GROUP_INVITE_KEY_SUFFIX = ":invites"
+)
+
+// 次数未用完时占用一次，返回 -1 表示邀请码不存在或已过期，-2 表示次数已用完
+var useInviteScript = redis.NewScript(`
+if redis.call('exists', KEYS[1]) == 0 then
+	return -1
+end
+local maxUses = tonumber(redis.call('hget', KEYS[1], 'maxUses'))
+local uses = tonumber(redis.call('hget', KEYS[1], 'uses'))
+if maxUses > 0 and uses >= maxUses then
+	return -2
+end
+return redis.call('hincrby', KEYS[1], 'uses', 1)
+`)
+
+// 邀请码仍然存在时归还一次，避免为已过期的邀请码重新创建 key
+var releaseInviteScript = redis.NewScript(`
+if redis.call('exists', KEYS[1]) == 0 then
+	return 0
+end
+return redis.call('hincrby', KEYS[1], 'uses', -1)
+`)
+
+type InviteRepository interface {
+	GetLogger() log.Logger
+	InsertInvite(ctx context.Context, invite *model.GroupInvite) error
+	FindInvite(ctx context.Context, code string) (*model.GroupInvite, error)
+	FindGroupInvites(ctx context.Context, groupId int64) ([]*model.GroupInvite, error)
+	UpdateInviteUse(ctx context.Context, code string) error
+	UpdateInviteRelease(ctx context.Context, code string) error
+	DeleteInvite(ctx context.Context, groupId int64, code string) error
+}
+
+// 邀请码保存在 redis: invite:<code> 记录邀请信息，设置有效期时由 redis 自动过期
+// group:<groupId>:invites 记录群下的邀请码，列出时顺带清理已过期的邀请码
+type inviteRepository struct {
+	rdb    *redis.Client
+	logger log.Logger
+}
+
+func NewInviteRepository(rdb *redis.Client, logger log.Logger) InviteRepository {
+	return &inviteRepository{
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+func (r *inviteRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+func (r *inviteRepository) key(code string) string {
+	return INVITE_KEY_PREFIX + code
+}
+
+func (r *inviteRepository) groupKey(groupId int64) string {
+	return GROUP_INVITE_KEY_PREFIX + strconv.FormatInt(groupId, 10) + GROUP_INVITE_KEY_SUFFIX
+}
+
+func (r *inviteRepository) InsertInvite(ctx context.Context, invite *model.GroupInvite) error {
+	key := r.key(invite.Code)
+	var expireTime int64
+	if !invite.ExpireTime.IsZero() {
+		expireTime = invite.ExpireTime.Unix()
+	}
+	pipe := r.rdb.TxPipeline()
+	pipe.HSet(ctx, key, map[string]any{
+		"groupId":     invite.GroupId,
+		"creatorId":   invite.CreatorId,
+		"role":        invite.Role,
+		"maxUses":     invite.MaxUses,
+		"uses":        0,
+		"expireTime":  expireTime,
+		"createdTime": invite.CreatedTime.Unix(),
+	})
+	if expireTime > 0 {
+		pipe.ExpireAt(ctx, key, invite.ExpireTime)
+	}
+	pipe.SAdd(ctx, r.groupKey(invite.GroupId), invite.Code)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis invite insert",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+func (r *inviteRepository) FindInvite(ctx context.Context, code string) (*model.GroupInvite, error) {
+	values, err := r.rdb.HGetAll(ctx, r.key(code)).Result()
+	if err != nil || len(values) == 0 {
+		if err != nil {
+			log.Error(
+				r.logger,
+				"redis invite select",
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+		}
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return r.parseInvite(code, values), nil
+}
+
+func (r *inviteRepository) parseInvite(code string, values map[string]string) *model.GroupInvite {
+	groupId, _ := strconv.ParseInt(values["groupId"], 10, 64)
+	creatorId, _ := strconv.ParseInt(values["creatorId"], 10, 64)
+	maxUses, _ := strconv.Atoi(values["maxUses"])
+	uses, _ := strconv.Atoi(values["uses"])
+	expireTime, _ := strconv.ParseInt(values["expireTime"], 10, 64)
+	createdTime, _ := strconv.ParseInt(values["createdTime"], 10, 64)
+	invite := &model.GroupInvite{
+		Code:        code,
+		GroupId:     groupId,
+		CreatorId:   creatorId,
+		Role:        values["role"],
+		MaxUses:     maxUses,
+		Uses:        uses,
+		CreatedTime: time.Unix(createdTime, 0),
+	}
+	if expireTime > 0 {
+		invite.ExpireTime = time.Unix(expireTime, 0)
+	}
+	return invite
+}
+
+// 群下仍然有效的邀请码，按创建时间递减
+func (r *inviteRepository) FindGroupInvites(ctx context.Context, groupId int64) ([]*model.GroupInvite, error) {
+	groupKey := r.groupKey(groupId)
+	codes, err := r.rdb.SMembers(ctx, groupKey).Result()
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis group invites select",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	pipe := r.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(codes))
+	for i, code := range codes {
+		cmds[i] = pipe.HGetAll(ctx, r.key(code))
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis group invites select",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	invites := make([]*model.GroupInvite, 0, len(codes))
+	var expired []any
+	for i, cmd := range cmds {
+		values := cmd.Val()
+		if len(values) == 0 {
+			expired = append(expired, codes[i])
+			continue
+		}
+		invites = append(invites, r.parseInvite(codes[i], values))
+	}
+	if len(expired) > 0 {
+		r.rdb.SRem(ctx, groupKey, expired...)
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		return invites[i].CreatedTime.After(invites[j].CreatedTime)
+	})
+	return invites, nil
+}
+
+// 占用一次使用次数，邀请码无效或次数用完时返回 ErrGroupInviteInvalid
+func (r *inviteRepository) UpdateInviteUse(ctx context.Context, code string) error {
+	uses, err := useInviteScript.Run(ctx, r.rdb, []string{r.key(code)}).Int64()
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis invite use",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	if uses < 0 {
+		return &model.DError{
+			Code:    constant.ErrGroupInviteInvalid,
+			Message: constant.MsgGroupInviteInvalid,
+		}
+	}
+	return nil
+}
+
+// 加群失败时归还占用的次数
+func (r *inviteRepository) UpdateInviteRelease(ctx context.Context, code string) error {
+	err := releaseInviteScript.Run(ctx, r.rdb, []string{r.key(code)}).Err()
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis invite release",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+func (r *inviteRepository) DeleteInvite(ctx context.Context, groupId int64, code string) error {
+	pipe := r.rdb.TxPipeline()
+	pipe.Del(ctx, r.key(code))
+	pipe.SRem(ctx, r.groupKey(groupId), code)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis invite delete",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
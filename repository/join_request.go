@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+type JoinRequestRepository interface {
+	GetLogger() log.Logger
+	UpsertJoinRequest(ctx context.Context, joinRequest *model.JoinRequest) error
+	FindJoinRequest(ctx context.Context, joinRequest *model.JoinRequest) error
+	FindJoinRequests(ctx context.Context, groupId int64, status int, page *model.Page[*model.JoinRequest]) error
+	UpdateJoinRequest(ctx context.Context, requestId int64, fromStatus, toStatus int, handlerId int64) error
+}
+
+type joinRequestRepository struct {
+	db     DBTX
+	logger log.Logger
+}
+
+func NewJoinRequestRepository(db DBTX, logger log.Logger) JoinRequestRepository {
+	return &joinRequestRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *joinRequestRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 每个用户在每个群只保留一条申请，重新申请时覆盖为待处理
+func (r *joinRequestRepository) UpsertJoinRequest(ctx context.Context, joinRequest *model.JoinRequest) error {
+	upsertSql := `
+		insert into im_group_join_request(group_id,user_id,user_nickname,message,status)
+		values
+		(?,?,?,?,?)
+		on duplicate key update
+			request_id = last_insert_id(request_id),
+			user_nickname = values(user_nickname),
+			message = values(message),
+			status = values(status),
+			handler_id = 0,
+			created_time = current_timestamp
+	`
+	result, err := r.db.ExecContext(
+		ctx,
+		upsertSql,
+		joinRequest.GroupId,
+		joinRequest.UserId,
+		joinRequest.UserNickname,
+		joinRequest.Message,
+		joinRequest.Status,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			upsertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	joinRequest.RequestId, err = result.LastInsertId()
+	if err != nil {
+		log.Error(
+			r.logger,
+			upsertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	return nil
+}
+
+func (r *joinRequestRepository) FindJoinRequest(ctx context.Context, joinRequest *model.JoinRequest) error {
+	selectSql := `
+		select igjr.group_id,igjr.user_id,iu.user_name,igjr.user_nickname,igjr.message,igjr.status,igjr.handler_id,unix_timestamp(igjr.created_time)
+		from im_group_join_request igjr
+		left join im_users iu
+		on igjr.user_id = iu.user_id
+		where
+			igjr.request_id = ?
+	`
+	var createdTime int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		joinRequest.RequestId,
+	).Scan(
+		&joinRequest.GroupId,
+		&joinRequest.UserId,
+		&joinRequest.UserName,
+		&joinRequest.UserNickname,
+		&joinRequest.Message,
+		&joinRequest.Status,
+		&joinRequest.HandlerId,
+		&createdTime,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	joinRequest.CreatedTime = time.Unix(createdTime, 0)
+	return nil
+}
+
+// 按申请时间递增分页列出群内指定状态的申请
+func (r *joinRequestRepository) FindJoinRequests(ctx context.Context, groupId int64, status int, page *model.Page[*model.JoinRequest]) error {
+	selectSql := `
+		select igjr.request_id,igjr.user_id,iu.user_name,igjr.user_nickname,igjr.message,igjr.handler_id,unix_timestamp(igjr.created_time)
+		from im_group_join_request igjr
+		left join im_users iu
+		on igjr.user_id = iu.user_id
+		where
+			igjr.group_id = ? and igjr.status = ?
+		order by igjr.created_time, igjr.request_id
+		limit ? offset ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		groupId,
+		status,
+		page.PageSize,
+		(page.CurrentPage-1)*page.PageSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		joinRequest := &model.JoinRequest{
+			GroupId: groupId,
+			Status:  status,
+		}
+		var createdTime int64
+		err = rows.Scan(
+			&joinRequest.RequestId,
+			&joinRequest.UserId,
+			&joinRequest.UserName,
+			&joinRequest.UserNickname,
+			&joinRequest.Message,
+			&joinRequest.HandlerId,
+			&createdTime,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		joinRequest.CreatedTime = time.Unix(createdTime, 0)
+		page.Items = append(page.Items, joinRequest)
+	}
+	page.Total = len(page.Items)
+	return nil
+}
+
+// 仅当申请仍处于 fromStatus 时更新，用于避免重复处理
+func (r *joinRequestRepository) UpdateJoinRequest(ctx context.Context, requestId int64, fromStatus, toStatus int, handlerId int64) error {
+	updateSql := `
+		update im_group_join_request
+		set
+			status = ?,
+			handler_id = ?
+		where
+			request_id = ? and status = ?
+	`
+	result, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		toStatus,
+		handlerId,
+		requestId,
+		fromStatus,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	rowChange, err := result.RowsAffected()
+	if err != nil || rowChange != 1 {
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,731 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+type MessageRepository interface {
+	GetLogger() log.Logger
+	FindSingleId(ctx context.Context, userId, peerId int64) (int64, error)
+	InsertMessage(ctx context.Context, message *model.Message, timelines []*model.Timeline) error
+	FindTimelineMessages(ctx context.Context, userId int64, cursor *model.SyncCursor, limit int) ([]*model.MessageItem, error)
+	FindGroupTimelines(ctx context.Context, groupIds []int64) ([]int64, error)
+	UpdateSingleRead(ctx context.Context, readReceipt *model.ReadReceipt, fromStatus []string, toStatus string) ([]int64, error)
+	UpdateGroupRead(ctx context.Context, readReceipt *model.ReadReceipt) ([]int64, error)
+	FindMessage(ctx context.Context, messageId int64) (*model.Message, error)
+	UpdateRecall(ctx context.Context, messageId int64, fromStatus []string, toStatus string) error
+}
+
+type messageRepository struct {
+	db      DBTX
+	seqRepo SequenceRepository
+	logger  log.Logger
+}
+
+func NewMessageRepository(db DBTX, seqRepo SequenceRepository, logger log.Logger) MessageRepository {
+	return &messageRepository{
+		db:      db,
+		seqRepo: seqRepo,
+		logger:  logger,
+	}
+}
+
+func (r *messageRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 查找双方已经建立(已接受且未删除)的单聊，不区分邀请方向
+func (r *messageRepository) FindSingleId(ctx context.Context, userId, peerId int64) (int64, error) {
+	selectSql := `
+		select single_id
+		from im_single_chat
+		where
+			deleted = ?
+			and ((inviter_id = ? and invitee_id = ?) or (inviter_id = ? and invitee_id = ?))
+		limit 1
+	`
+	var singleId int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		0,
+		userId,
+		peerId,
+		peerId,
+		userId,
+	).Scan(
+		&singleId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return -1, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return singleId, nil
+}
+
+// 在同一事务中写入消息本体以及对应的时间线，时间线的序列号在事务内分配
+func (r *messageRepository) InsertMessage(ctx context.Context, message *model.Message, timelines []*model.Timeline) error {
+	insertSql := `
+		insert into im_message(sender,receiver,dialog_type,type,content,blob_id,status,send_time)
+		values
+		(
+			?,?,?,
+			(select type_id from im_message_type where type_name = ? and deleted = 0),
+			?,?,
+			(select status_id from im_message_status where status_name = ? and deleted = 0),
+			from_unixtime(?)
+		)
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	result, err := tx.ExecContext(
+		ctx,
+		insertSql,
+		message.Sender,
+		message.Receiver,
+		message.DialogType,
+		message.Type,
+		message.StoredContent(),
+		message.BlobId,
+		message.Status,
+		message.SendTime.Unix(),
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	message.MessageId, err = result.LastInsertId()
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	insertSql = `
+		insert into im_timeline(timeline_type,timeline_id,sequence_id,sender,dialog_type,message_id)
+		values
+		(?,?,?,?,?,?)
+	`
+	// 按固定顺序锁住各时间线的水位行，避免并发写入同一批时间线时死锁
+	ordered := make([]*model.Timeline, len(timelines))
+	copy(ordered, timelines)
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].TimelineType != ordered[j].TimelineType {
+			return ordered[i].TimelineType < ordered[j].TimelineType
+		}
+		return ordered[i].TimelineId < ordered[j].TimelineId
+	})
+	for _, timeline := range ordered {
+		timeline.SequenceId, err = r.seqRepo.NextSequence(ctx, tx, timeline.TimelineType, timeline.TimelineId)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		timeline.MessageId = message.MessageId
+		_, err = tx.ExecContext(
+			ctx,
+			insertSql,
+			timeline.TimelineType,
+			timeline.TimelineId,
+			timeline.SequenceId,
+			timeline.Sender,
+			timeline.DialogType,
+			timeline.MessageId,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				insertSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			tx.Rollback()
+			return &model.DError{
+				Code:    constant.ErrSqlInsertFail,
+				Message: constant.MsgSqlInsertFail,
+			}
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
+// 按序列号递增读取时间线上游标之后的消息，群时间线只返回用户入群之后写入的
+func (r *messageRepository) FindTimelineMessages(ctx context.Context, userId int64, cursor *model.SyncCursor, limit int) ([]*model.MessageItem, error) {
+	selectSql := `
+		select it.timeline_type,it.timeline_id,it.sequence_id,im.message_id,im.sender,im.receiver,im.dialog_type,
+			imt.type_name,im.content,ims.status_name,im.read_count,unix_timestamp(im.send_time)
+		from im_timeline it
+		join im_message im on it.message_id = im.message_id
+		left join im_message_type imt on im.type = imt.type_id
+		left join im_message_status ims on im.status = ims.status_id
+		where
+			it.timeline_type = ? and it.timeline_id = ? and it.sequence_id > ? and it.deleted = ? and im.deleted = ?
+			and (it.timeline_type <> ? or it.created_time >= (
+				select igu.created_time from im_groups_users igu
+				where igu.group_id = it.timeline_id and igu.user_id = ? and igu.deleted = ?
+			))
+		order by it.sequence_id
+		limit ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		cursor.TimelineType,
+		cursor.TimelineId,
+		cursor.SequenceId,
+		0,
+		0,
+		TIMELINE_GROUP,
+		userId,
+		0,
+		limit,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	var items []*model.MessageItem
+	for rows.Next() {
+		var item model.MessageItem
+		var sendTime int64
+		err = rows.Scan(
+			&item.TimelineType,
+			&item.TimelineId,
+			&item.SequenceId,
+			&item.MessageId,
+			&item.Sender,
+			&item.Receiver,
+			&item.DialogType,
+			&item.Type,
+			&item.Text,
+			&item.Status,
+			&item.ReadCount,
+			&sendTime,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		item.SendTime = time.Unix(sendTime, 0)
+		items = append(items, &item)
+	}
+	return items, nil
+}
+
+// 筛选出写入过群时间线的群，群在读写扩散之间切换后群时间线上的历史消息仍需同步
+func (r *messageRepository) FindGroupTimelines(ctx context.Context, groupIds []int64) ([]int64, error) {
+	if len(groupIds) == 0 {
+		return nil, nil
+	}
+	selectSql := `
+		select timeline_id
+		from im_timeline_sequence
+		where
+			timeline_type = ? and timeline_id in (` + placeholders(len(groupIds)) + `)
+	`
+	args := []any{TIMELINE_GROUP}
+	for _, groupId := range groupIds {
+		args = append(args, groupId)
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		args...,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	var timelineIds []int64
+	for rows.Next() {
+		var timelineId int64
+		err = rows.Scan(
+			&timelineId,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		timelineIds = append(timelineIds, timelineId)
+	}
+	return timelineIds, nil
+}
+
+// 将收件箱中对方发来、序列号不超过已读位置且状态允许迁移的消息更新为目标状态，返回被更新的消息
+func (r *messageRepository) UpdateSingleRead(ctx context.Context, readReceipt *model.ReadReceipt, fromStatus []string, toStatus string) ([]int64, error) {
+	selectSql := `
+		select im.message_id
+		from im_timeline it
+		join im_message im on it.message_id = im.message_id
+		join im_message_status ims on im.status = ims.status_id
+		where
+			it.timeline_type = ? and it.timeline_id = ? and it.sequence_id <= ? and it.dialog_type = ? and it.deleted = ?
+			and im.sender = ? and im.receiver = ? and ims.status_name in (` + placeholders(len(fromStatus)) + `)
+		for update
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	args := []any{
+		readReceipt.TimelineType,
+		readReceipt.TimelineId,
+		readReceipt.SequenceId,
+		readReceipt.DialogType,
+		0,
+		readReceipt.PeerId,
+		readReceipt.ReaderId,
+	}
+	for _, status := range fromStatus {
+		args = append(args, status)
+	}
+	messageIds, err := r.queryIds(ctx, tx, selectSql, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(messageIds) > 0 {
+		updateSql := `
+			update im_message
+			set
+				status = (select status_id from im_message_status where status_name = ? and deleted = 0)
+			where
+				message_id in (` + placeholders(len(messageIds)) + `)
+		`
+		args = []any{toStatus}
+		for _, messageId := range messageIds {
+			args = append(args, messageId)
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			updateSql,
+			args...,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				updateSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			tx.Rollback()
+			return nil, &model.DError{
+				Code:    constant.ErrSqlUpdateFail,
+				Message: constant.MsgSqlUpdateFail,
+			}
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return messageIds, nil
+}
+
+// 推进成员在群内的已读位置，并为新读到的群消息累加已读人数，返回新读到的消息
+func (r *messageRepository) UpdateGroupRead(ctx context.Context, readReceipt *model.ReadReceipt) ([]int64, error) {
+	selectSql := `
+		select timeline_type,timeline_id,sequence_id
+		from im_group_read
+		where
+			group_id = ? and user_id = ?
+		for update
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	var lastTimelineType int
+	var lastTimelineId, lastSequenceId int64
+	err = tx.QueryRowContext(
+		ctx,
+		selectSql,
+		readReceipt.PeerId,
+		readReceipt.ReaderId,
+	).Scan(
+		&lastTimelineType,
+		&lastTimelineId,
+		&lastSequenceId,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	// 群在读写扩散之间切换后已读位置所在的时间线会变化，新时间线从头计算
+	if lastTimelineType != readReceipt.TimelineType || lastTimelineId != readReceipt.TimelineId {
+		lastSequenceId = 0
+	}
+	if readReceipt.SequenceId <= lastSequenceId {
+		tx.Rollback()
+		return nil, nil
+	}
+	selectSql = `
+		select im.message_id
+		from im_timeline it
+		join im_message im on it.message_id = im.message_id
+		where
+			it.timeline_type = ? and it.timeline_id = ? and it.sequence_id > ? and it.sequence_id <= ? and it.deleted = ?
+			and im.receiver = ? and im.dialog_type = ? and im.sender <> ?
+		for update
+	`
+	messageIds, err := r.queryIds(
+		ctx,
+		tx,
+		selectSql,
+		readReceipt.TimelineType,
+		readReceipt.TimelineId,
+		lastSequenceId,
+		readReceipt.SequenceId,
+		0,
+		readReceipt.PeerId,
+		readReceipt.DialogType,
+		readReceipt.ReaderId,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(messageIds) > 0 {
+		updateSql := `
+			update im_message
+			set
+				read_count = read_count + 1
+			where
+				message_id in (` + placeholders(len(messageIds)) + `)
+		`
+		var args []any
+		for _, messageId := range messageIds {
+			args = append(args, messageId)
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			updateSql,
+			args...,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				updateSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			tx.Rollback()
+			return nil, &model.DError{
+				Code:    constant.ErrSqlUpdateFail,
+				Message: constant.MsgSqlUpdateFail,
+			}
+		}
+	}
+	upsertSql := `
+		insert into im_group_read(group_id,user_id,timeline_type,timeline_id,sequence_id)
+		values
+		(?,?,?,?,?)
+		on duplicate key update timeline_type = ?, timeline_id = ?, sequence_id = ?
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		upsertSql,
+		readReceipt.PeerId,
+		readReceipt.ReaderId,
+		readReceipt.TimelineType,
+		readReceipt.TimelineId,
+		readReceipt.SequenceId,
+		readReceipt.TimelineType,
+		readReceipt.TimelineId,
+		readReceipt.SequenceId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			upsertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		tx.Rollback()
+		return nil, &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return messageIds, nil
+}
+
+// 查找未删除的消息
+func (r *messageRepository) FindMessage(ctx context.Context, messageId int64) (*model.Message, error) {
+	selectSql := `
+		select im.message_id,im.sender,im.receiver,im.dialog_type,imt.type_name,ims.status_name,unix_timestamp(im.send_time)
+		from im_message im
+		left join im_message_type imt on im.type = imt.type_id
+		left join im_message_status ims on im.status = ims.status_id
+		where
+			im.message_id = ? and im.deleted = ?
+	`
+	var message model.Message
+	var sendTime int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		messageId,
+		0,
+	).Scan(
+		&message.MessageId,
+		&message.Sender,
+		&message.Receiver,
+		&message.DialogType,
+		&message.Type,
+		&message.Status,
+		&sendTime,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	message.SendTime = time.Unix(sendTime, 0)
+	return &message, nil
+}
+
+// 撤回消息: 状态迁移为目标状态并清空内容，状态已不允许迁移时视为失败
+func (r *messageRepository) UpdateRecall(ctx context.Context, messageId int64, fromStatus []string, toStatus string) error {
+	updateSql := `
+		update im_message
+		set
+			status = (select status_id from im_message_status where status_name = ? and deleted = 0),
+			content = '',
+			blob_id = ''
+		where
+			message_id = ? and deleted = ?
+			and status in (select status_id from im_message_status where status_name in (` + placeholders(len(fromStatus)) + `))
+	`
+	args := []any{
+		toStatus,
+		messageId,
+		0,
+	}
+	for _, status := range fromStatus {
+		args = append(args, status)
+	}
+	result, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		args...,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	rowChange, err := result.RowsAffected()
+	if err != nil || rowChange != 1 {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"rowChange": rowChange,
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return nil
+}
+
+// 事务内查询单列 id
+func (r *messageRepository) queryIds(ctx context.Context, tx *sql.Tx, selectSql string, args ...any) ([]int64, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		selectSql,
+		args...,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -3,10 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	MYSQL_ER_DUP_ENTRY = 1062 // 唯一键冲突
 )
 
 type Result = sql.Result
@@ -40,3 +46,14 @@ func NewMysqlDB(connUrl string) *sql.DB {
 	}
 	return db
 }
+
+// 写入是否因唯一键冲突失败
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == MYSQL_ER_DUP_ENTRY
+}
+
+// 生成 in (...) 使用的占位符，n 必须大于 0
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+const (
+	TIMELINE_USER  = 1 // 用户收件箱
+	TIMELINE_GROUP = 2 // 群时间线
+)
+
+type SequenceRepository interface {
+	GetLogger() log.Logger
+	NextSequence(ctx context.Context, tx *sql.Tx, timelineType int, timelineId int64) (int64, error)
+}
+
+type sequenceRepository struct {
+	db     DBTX
+	logger log.Logger
+}
+
+func NewSequenceRepository(db DBTX, logger log.Logger) SequenceRepository {
+	return &sequenceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *sequenceRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 在写入消息的事务中为时间线分配严格递增的序列号
+// 事务锁住时间线的水位行直到提交，同一时间线的分配与写入串行，序列号较小的消息一定先提交
+// 序列号只由水位行分配，事务回滚时水位一并回滚，不会留下空洞
+func (r *sequenceRepository) NextSequence(ctx context.Context, tx *sql.Tx, timelineType int, timelineId int64) (int64, error) {
+	floor, err := r.lockSequence(ctx, tx, timelineType, timelineId)
+	if err != nil {
+		return -1, err
+	}
+	seq := floor + 1
+	updateSql := `
+		update im_timeline_sequence
+		set
+			sequence_id = ?
+		where
+			timeline_type = ? and timeline_id = ?
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		updateSql,
+		seq,
+		timelineType,
+		timelineId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return -1, &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return seq, nil
+}
+
+// 锁住时间线的水位行并返回已分配的最大序列号，首次写入的时间线从 0 开始
+func (r *sequenceRepository) lockSequence(ctx context.Context, tx *sql.Tx, timelineType int, timelineId int64) (int64, error) {
+	upsertSql := `
+		insert into im_timeline_sequence(timeline_type,timeline_id,sequence_id)
+		values
+		(?,?,0)
+		on duplicate key update sequence_id = sequence_id
+	`
+	_, err := tx.ExecContext(
+		ctx,
+		upsertSql,
+		timelineType,
+		timelineId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			upsertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return -1, &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	selectSql := `
+		select sequence_id
+		from im_timeline_sequence
+		where
+			timeline_type = ? and timeline_id = ?
+		for update
+	`
+	var floor int64
+	err = tx.QueryRowContext(
+		ctx,
+		selectSql,
+		timelineType,
+		timelineId,
+	).Scan(
+		&floor,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return -1, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	return floor, nil
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
@@ -13,9 +16,15 @@ type SingleRepository interface {
 	InsertUnAccepted(ctx context.Context, singleInvite *model.SingleInvite) error
 	FindByInviter(ctx context.Context, singleInviter *model.SingleInviter) error
 	FindByInvitee(ctx context.Context, singleInvitee *model.SingleInvitee) error
+	FindParticipants(ctx context.Context, single *model.Single) error
+	FindStatus(ctx context.Context, single *model.Single) (bool, error)
+	FindRequests(ctx context.Context, userId int64, incoming bool, status int, page *model.Page[*model.SingleRequest]) error
+	FindContacts(ctx context.Context, userId int64, status int, tagId int64, page *model.Page[*model.Contact]) error
 	UpdateByInviter(ctx context.Context, singleInviter *model.SingleInviter) error
 	UpdateByInvitee(ctx context.Context, singleInvitee *model.SingleInvitee) error
 	UpdateByAccept(ctx context.Context, singleAccept *model.SingleAccept) error
+	UpdateStatus(ctx context.Context, singleId, userId int64, incoming bool, fromStatus, toStatus int) error
+	UpdateExpired(ctx context.Context, before time.Time, fromStatus, toStatus int) (int64, error)
 	Update(ctx context.Context, single *model.Single) error
 	Delete(ctx context.Context, single *model.SingleDelete) error
 }
@@ -36,12 +45,21 @@ func (r *singleRepository) GetLogger() log.Logger {
 	return r.logger
 }
 
-// 插入新的 single row,默认为逻辑删除
+// 插入新的 single row,默认为逻辑删除,已有记录(被拒绝、取消、过期或删除)时重新发起申请
+// 两人之间只有一条记录，反向重新申请时改写记录的邀请方向
 func (r *singleRepository) InsertUnAccepted(ctx context.Context, singleInvite *model.SingleInvite) error {
 	insertSql := `
-		insert into im_single_chat(single_id,inviter_id,invitee_id,invitee_nickname,inviter_disturb,deleted)
+		insert into im_single_chat(single_id,inviter_id,invitee_id,invitee_nickname,inviter_disturb,status,deleted)
 		values
-		(?,?,?,?,?,?)
+		(?,?,?,?,?,?,?)
+		on duplicate key update
+		inviter_id = values(inviter_id),
+		invitee_id = values(invitee_id),
+		invitee_nickname = values(invitee_nickname),
+		inviter_disturb = values(inviter_disturb),
+		status = values(status),
+		deleted = values(deleted),
+		created_time = current_timestamp
 	`
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -58,6 +76,7 @@ func (r *singleRepository) InsertUnAccepted(ctx context.Context, singleInvite *m
 		singleInvite.InviteeId,
 		singleInvite.InviteeNickname,
 		singleInvite.InviterDisturb,
+		singleInvite.Status,
 		singleInvite.Deleted,
 	)
 	if err != nil {
@@ -74,8 +93,9 @@ func (r *singleRepository) InsertUnAccepted(ctx context.Context, singleInvite *m
 			Message: constant.MsgOperationFail,
 		}
 	}
+	// on duplicate key update 更新已有记录时影响行数为 2
 	rowChange, err := result.RowsAffected()
-	if err != nil || rowChange > 1 {
+	if err != nil || rowChange > 2 {
 		tx.Rollback()
 		log.Error(
 			r.logger,
@@ -181,6 +201,38 @@ func (r *singleRepository) FindByInvitee(ctx context.Context, singleInvitee *mod
 	return nil
 }
 
+func (r *singleRepository) FindParticipants(ctx context.Context, single *model.Single) error {
+	selectSql := `
+		select inviter_id,invitee_id
+		from im_single_chat
+		where
+		single_id = ? and deleted = ?
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		single.SingleId,
+		0,
+	).Scan(
+		&single.InviterId,
+		&single.InviteeId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
 func (r *singleRepository) UpdateByInviter(ctx context.Context, singleInviter *model.SingleInviter) error {
 	updateSql := `
 		update im_single_chat
@@ -340,9 +392,10 @@ func (r *singleRepository) UpdateByAccept(ctx context.Context, singleAccept *mod
 		set
 			inviter_nickname = ?,
 			invitee_disturb = ?,
+			status = ?,
 			deleted = ?
 		where
-		single_id = ? and deleted = ?
+		single_id = ? and invitee_id = ? and status = ?
 	`
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -356,9 +409,11 @@ func (r *singleRepository) UpdateByAccept(ctx context.Context, singleAccept *mod
 		updateSql,
 		singleAccept.InviterNickname,
 		singleAccept.InviteeDisturb,
+		singleAccept.Status,
 		singleAccept.Deleted,
 		singleAccept.SingleId,
-		singleAccept.Deleted+1,
+		singleAccept.InviteeId,
+		singleAccept.FromStatus,
 	)
 	if err != nil {
 		tx.Rollback()
@@ -374,16 +429,10 @@ func (r *singleRepository) UpdateByAccept(ctx context.Context, singleAccept *mod
 			Message: constant.MsgOperationFail,
 		}
 	}
+	// 只有处于待处理状态的申请可以被接受
 	rowChange, err := result.RowsAffected()
-	if err != nil || rowChange > 1 {
+	if err != nil || rowChange != 1 {
 		tx.Rollback()
-		log.Error(
-			r.logger,
-			"update fail",
-			map[string]any{
-				"error": err.Error(),
-			},
-		)
 		return &model.DError{
 			Code:    constant.ErrSqlUpdateFail,
 			Message: constant.MsgOperationFail,
@@ -554,3 +603,292 @@ func (r *singleRepository) Delete(ctx context.Context, single *model.SingleDelet
 	}
 	return nil
 }
+
+// 查询两人之间已有记录的邀请方向与申请状态，不区分邀请方向，没有记录时返回 false
+func (r *singleRepository) FindStatus(ctx context.Context, single *model.Single) (bool, error) {
+	selectSql := `
+		select inviter_id,invitee_id,status,deleted
+		from im_single_chat
+		where
+		single_id = ?
+	`
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		single.SingleId,
+	).Scan(
+		&single.InviterId,
+		&single.InviteeId,
+		&single.Status,
+		&single.Deleted,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return true, nil
+}
+
+// 收到的申请按 invitee_id 查找，发出的申请按 inviter_id 查找，按申请时间递减
+func (r *singleRepository) FindRequests(ctx context.Context, userId int64, incoming bool, status int, page *model.Page[*model.SingleRequest]) error {
+	selectSql := `
+		select isc.single_id,isc.inviter_id,iu1.user_name,isc.invitee_id,iu2.user_name,isc.invitee_nickname,isc.status,unix_timestamp(isc.created_time)
+		from im_single_chat isc
+		left join im_users iu1 on isc.inviter_id = iu1.user_id
+		left join im_users iu2 on isc.invitee_id = iu2.user_id
+		where
+		isc.inviter_id = ? and isc.status = ?
+		order by isc.created_time desc
+		limit ? offset ?
+	`
+	if incoming {
+		selectSql = `
+			select isc.single_id,isc.inviter_id,iu1.user_name,isc.invitee_id,iu2.user_name,isc.invitee_nickname,isc.status,unix_timestamp(isc.created_time)
+			from im_single_chat isc
+			left join im_users iu1 on isc.inviter_id = iu1.user_id
+			left join im_users iu2 on isc.invitee_id = iu2.user_id
+			where
+			isc.invitee_id = ? and isc.status = ?
+			order by isc.created_time desc
+			limit ? offset ?
+		`
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+		status,
+		page.PageSize,
+		(page.CurrentPage-1)*page.PageSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		request := &model.SingleRequest{}
+		var createdTime int64
+		err = rows.Scan(
+			&request.SingleId,
+			&request.InviterId,
+			&request.InviterName,
+			&request.InviteeId,
+			&request.InviteeName,
+			&request.InviteeNickname,
+			&request.Status,
+			&createdTime,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgOperationFail,
+			}
+		}
+		request.CreatedTime = time.Unix(createdTime, 0)
+		page.Items = append(page.Items, request)
+	}
+	page.Total = len(page.Items)
+	return nil
+}
+
+// 仅当申请仍处于 fromStatus 时更新，incoming 为 true 时 userId 为受邀人，否则为邀请人
+func (r *singleRepository) UpdateStatus(ctx context.Context, singleId, userId int64, incoming bool, fromStatus, toStatus int) error {
+	updateSql := `
+		update im_single_chat
+		set
+		status = ?
+		where
+		single_id = ? and inviter_id = ? and status = ?
+	`
+	if incoming {
+		updateSql = `
+			update im_single_chat
+			set
+			status = ?
+			where
+			single_id = ? and invitee_id = ? and status = ?
+		`
+	}
+	result, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		toStatus,
+		singleId,
+		userId,
+		fromStatus,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	rowChange, err := result.RowsAffected()
+	if err != nil || rowChange != 1 {
+		return &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 将 before 之前发出且仍未处理的申请标记为过期，返回过期的数量
+func (r *singleRepository) UpdateExpired(ctx context.Context, before time.Time, fromStatus, toStatus int) (int64, error) {
+	updateSql := `
+		update im_single_chat
+		set
+		status = ?
+		where
+		status = ? and created_time < from_unixtime(?)
+	`
+	result, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		toStatus,
+		fromStatus,
+		before.Unix(),
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return 0, &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	rowChange, err := result.RowsAffected()
+	if err != nil {
+		return 0, &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return rowChange, nil
+}
+
+// 已建立的单聊，无论用户是邀请人还是受邀人，按备注(未设置时为用户名)的拼音或字母顺序排列
+// tagId 不为 0 时只返回关联了该用户此标签的单聊
+func (r *singleRepository) FindContacts(ctx context.Context, userId int64, status int, tagId int64, page *model.Page[*model.Contact]) error {
+	selectSql := `
+		select c.single_id,c.peer_id,iu.user_name,c.remark,c.disturb,coalesce(iud.avatar,'')
+		from (
+			select single_id,invitee_id as peer_id,invitee_nickname as remark,inviter_disturb as disturb
+			from im_single_chat
+			where inviter_id = ? and status = ? and deleted = ?
+			union all
+			select single_id,inviter_id,inviter_nickname,invitee_disturb
+			from im_single_chat
+			where invitee_id = ? and status = ? and deleted = ?
+		) c
+		join im_users iu on iu.user_id = c.peer_id and iu.deleted = ?
+		left join im_users_detail iud on iud.user_id = c.peer_id
+		where
+			? = 0 or exists (
+				select 1 from im_tag_item iti
+				join im_tag it on it.tag_id = iti.tag_id
+				where iti.tag_id = ? and it.user_id = ? and iti.dialog_type = ? and iti.dialog_id = c.single_id
+			)
+		order by (case when c.remark = '' then iu.user_name else c.remark end) collate utf8mb4_zh_0900_as_cs, c.peer_id
+		limit ? offset ?
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+		status,
+		0,
+		userId,
+		status,
+		0,
+		0,
+		tagId,
+		tagId,
+		userId,
+		DIALOG_SINGLE,
+		page.PageSize,
+		(page.CurrentPage-1)*page.PageSize,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		contact := &model.Contact{}
+		err = rows.Scan(
+			&contact.SingleId,
+			&contact.UserId,
+			&contact.UserName,
+			&contact.UserNickname,
+			&contact.UserDisturb,
+			&contact.UserAvatar,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgOperationFail,
+			}
+		}
+		page.Items = append(page.Items, contact)
+	}
+	page.Total = len(page.Items)
+	return nil
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+type TagRepository interface {
+	GetLogger() log.Logger
+	InsertTag(ctx context.Context, tag *model.Tag) (bool, error)
+	FindTag(ctx context.Context, tag *model.Tag) error
+	FindTags(ctx context.Context, userId int64) ([]*model.Tag, error)
+	UpdateTag(ctx context.Context, tag *model.Tag) (bool, error)
+	DeleteTag(ctx context.Context, tagId int64) error
+	InsertTagItem(ctx context.Context, item *model.TagItem) error
+	DeleteTagItem(ctx context.Context, item *model.TagItem) error
+}
+
+type tagRepository struct {
+	db     DBTX
+	logger log.Logger
+}
+
+func NewTagRepository(db DBTX, logger log.Logger) TagRepository {
+	return &tagRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *tagRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+// 同一用户的标签名不能重复，重名时返回 false
+func (r *tagRepository) InsertTag(ctx context.Context, tag *model.Tag) (bool, error) {
+	insertSql := `
+		insert into im_tag(user_id,tag_name)
+		values
+		(?,?)
+	`
+	result, err := r.db.ExecContext(
+		ctx,
+		insertSql,
+		tag.UserId,
+		tag.TagName,
+	)
+	if isDuplicateEntry(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	tag.TagId, err = result.LastInsertId()
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	tag.CreatedTime = time.Now()
+	return true, nil
+}
+
+// 只能查到 tag.UserId 自己的标签
+func (r *tagRepository) FindTag(ctx context.Context, tag *model.Tag) error {
+	selectSql := `
+		select it.tag_name,unix_timestamp(it.created_time),
+			(select count(*) from im_tag_item iti where iti.tag_id = it.tag_id)
+		from im_tag it
+		where
+			it.tag_id = ? and it.user_id = ?
+	`
+	var createdTime int64
+	err := r.db.QueryRowContext(
+		ctx,
+		selectSql,
+		tag.TagId,
+		tag.UserId,
+	).Scan(
+		&tag.TagName,
+		&createdTime,
+		&tag.ItemCount,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	tag.CreatedTime = time.Unix(createdTime, 0)
+	return nil
+}
+
+// 用户的全部标签，按创建顺序排列
+func (r *tagRepository) FindTags(ctx context.Context, userId int64) ([]*model.Tag, error) {
+	selectSql := `
+		select it.tag_id,it.tag_name,unix_timestamp(it.created_time),
+			(select count(*) from im_tag_item iti where iti.tag_id = it.tag_id)
+		from im_tag it
+		where
+			it.user_id = ?
+		order by it.tag_id
+	`
+	rows, err := r.db.QueryContext(
+		ctx,
+		selectSql,
+		userId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			selectSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrSqlSelectFail,
+			Message: constant.MsgSqlSelectFail,
+		}
+	}
+	defer rows.Close()
+	tags := make([]*model.Tag, 0)
+	for rows.Next() {
+		tag := &model.Tag{
+			UserId: userId,
+		}
+		var createdTime int64
+		err = rows.Scan(
+			&tag.TagId,
+			&tag.TagName,
+			&createdTime,
+			&tag.ItemCount,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				selectSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			return nil, &model.DError{
+				Code:    constant.ErrSqlSelectFail,
+				Message: constant.MsgSqlSelectFail,
+			}
+		}
+		tag.CreatedTime = time.Unix(createdTime, 0)
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// 改名为已有的标签名时返回 false
+func (r *tagRepository) UpdateTag(ctx context.Context, tag *model.Tag) (bool, error) {
+	updateSql := `
+		update im_tag
+		set
+			tag_name = ?
+		where
+			tag_id = ? and user_id = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		updateSql,
+		tag.TagName,
+		tag.TagId,
+		tag.UserId,
+	)
+	if isDuplicateEntry(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Error(
+			r.logger,
+			updateSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return false, &model.DError{
+			Code:    constant.ErrSqlUpdateFail,
+			Message: constant.MsgSqlUpdateFail,
+		}
+	}
+	return true, nil
+}
+
+// 删除标签的同时删除它的全部关联
+func (r *tagRepository) DeleteTag(ctx context.Context, tagId int64) error {
+	deleteItemSql := `
+		delete from im_tag_item
+		where
+			tag_id = ?
+	`
+	deleteSql := `
+		delete from im_tag
+		where
+			tag_id = ?
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTransactionBegin,
+			Message: constant.MsgTransactionBegin,
+		}
+	}
+	for _, execSql := range []string{deleteItemSql, deleteSql} {
+		_, err = tx.ExecContext(
+			ctx,
+			execSql,
+			tagId,
+		)
+		if err != nil {
+			log.Error(
+				r.logger,
+				execSql,
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+			tx.Rollback()
+			return &model.DError{
+				Code:    constant.ErrSqlDeleteFail,
+				Message: constant.MsgSqlDeleteFail,
+			}
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		log.Error(
+			r.logger,
+			"transaction commit fail",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrTransactionFail,
+			Message: constant.MsgTransactionFail,
+		}
+	}
+	return nil
+}
+
+// 重复关联时忽略
+func (r *tagRepository) InsertTagItem(ctx context.Context, item *model.TagItem) error {
+	insertSql := `
+		insert ignore into im_tag_item(tag_id,dialog_type,dialog_id)
+		values
+		(?,?,?)
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		insertSql,
+		item.TagId,
+		item.DialogType,
+		item.DialogId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			insertSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlInsertFail,
+			Message: constant.MsgSqlInsertFail,
+		}
+	}
+	return nil
+}
+
+func (r *tagRepository) DeleteTagItem(ctx context.Context, item *model.TagItem) error {
+	deleteSql := `
+		delete from im_tag_item
+		where
+			tag_id = ? and dialog_type = ? and dialog_id = ?
+	`
+	_, err := r.db.ExecContext(
+		ctx,
+		deleteSql,
+		item.TagId,
+		item.DialogType,
+		item.DialogId,
+	)
+	if err != nil {
+		log.Error(
+			r.logger,
+			deleteSql,
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrSqlDeleteFail,
+			Message: constant.MsgSqlDeleteFail,
+		}
+	}
+	return nil
+}
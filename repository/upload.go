@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+)
+
+const (
+	UPLOAD_KEY_PREFIX = "upload:"
+	UPLOAD_PARTS_KEY  = ":parts"
+)
+
+type UploadRepository interface {
+	GetLogger() log.Logger
+	InsertUpload(ctx context.Context, upload *model.Upload, ttl time.Duration) error
+	FindUpload(ctx context.Context, uploadId string) (*model.Upload, error)
+	InsertPart(ctx context.Context, uploadId string, part *model.UploadPart, ttl time.Duration) error
+	FindParts(ctx context.Context, uploadId string) ([]*model.UploadPart, error)
+	DeleteUpload(ctx context.Context, uploadId string) error
+}
+
+// 上传任务保存在 redis: upload:<id> 记录任务信息，upload:<id>:parts 记录已收到的分片及大小
+// 两个 key 共用同一个过期时间，超时未完成的任务由 redis 自动清理
+type uploadRepository struct {
+	rdb    *redis.Client
+	logger log.Logger
+}
+
+func NewUploadRepository(rdb *redis.Client, logger log.Logger) UploadRepository {
+	return &uploadRepository{
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+func (r *uploadRepository) GetLogger() log.Logger {
+	return r.logger
+}
+
+func (r *uploadRepository) key(uploadId string) string {
+	return UPLOAD_KEY_PREFIX + uploadId
+}
+
+func (r *uploadRepository) partsKey(uploadId string) string {
+	return UPLOAD_KEY_PREFIX + uploadId + UPLOAD_PARTS_KEY
+}
+
+func (r *uploadRepository) InsertUpload(ctx context.Context, upload *model.Upload, ttl time.Duration) error {
+	key := r.key(upload.UploadId)
+	pipe := r.rdb.TxPipeline()
+	pipe.HSet(ctx, key, map[string]any{
+		"size":        upload.Size,
+		"partCount":   upload.PartCount,
+		"createdTime": upload.CreatedTime.Unix(),
+	})
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis upload insert",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+func (r *uploadRepository) FindUpload(ctx context.Context, uploadId string) (*model.Upload, error) {
+	values, err := r.rdb.HGetAll(ctx, r.key(uploadId)).Result()
+	if err != nil || len(values) == 0 {
+		if err != nil {
+			log.Error(
+				r.logger,
+				"redis upload select",
+				map[string]any{
+					"error": err.Error(),
+				},
+			)
+		}
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	size, _ := strconv.ParseInt(values["size"], 10, 64)
+	partCount, _ := strconv.Atoi(values["partCount"])
+	createdTime, _ := strconv.ParseInt(values["createdTime"], 10, 64)
+	return &model.Upload{
+		UploadId:    uploadId,
+		Size:        size,
+		PartCount:   partCount,
+		CreatedTime: time.Unix(createdTime, 0),
+	}, nil
+}
+
+// 记录分片并顺延整个任务的过期时间
+func (r *uploadRepository) InsertPart(ctx context.Context, uploadId string, part *model.UploadPart, ttl time.Duration) error {
+	partsKey := r.partsKey(uploadId)
+	pipe := r.rdb.TxPipeline()
+	pipe.HSet(ctx, partsKey, strconv.Itoa(part.Part), part.Size)
+	pipe.Expire(ctx, partsKey, ttl)
+	pipe.Expire(ctx, r.key(uploadId), ttl)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis upload part insert",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 已收到的分片，按分片序号递增
+func (r *uploadRepository) FindParts(ctx context.Context, uploadId string) ([]*model.UploadPart, error) {
+	values, err := r.rdb.HGetAll(ctx, r.partsKey(uploadId)).Result()
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis upload parts select",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	parts := make([]*model.UploadPart, 0, len(values))
+	for field, value := range values {
+		part, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		size, _ := strconv.ParseInt(value, 10, 64)
+		parts = append(parts, &model.UploadPart{
+			Part: part,
+			Size: size,
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Part < parts[j].Part
+	})
+	return parts, nil
+}
+
+func (r *uploadRepository) DeleteUpload(ctx context.Context, uploadId string) error {
+	err := r.rdb.Del(ctx, r.key(uploadId), r.partsKey(uploadId)).Err()
+	if err != nil {
+		log.Error(
+			r.logger,
+			"redis upload delete",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
@@ -15,7 +15,7 @@ type UserRepository interface {
 	InsertOne(ctx context.Context, user *model.User) (*model.User, error)
 	FindOneById(ctx context.Context, userId int64) (*model.User, error)
 	FindOneByName(ctx context.Context, userName string) ([]model.User, error)
-	FindBasicLists(ctx context.Context, userSearch model.UserBasic, page *model.Page[model.UserBasic]) error
+	FindBasicLists(ctx context.Context, searcherId int64, userSearch model.UserBasic, page *model.Page[model.UserBasic]) error
 	UpdateOneById(ctx context.Context, user *model.User) (*model.User, error)
 	DeleteOneById(ctx context.Context, userId int64) error
 	GetLogger() log.Logger
@@ -254,12 +254,14 @@ func (r *userRepository) FindOneByName(ctx context.Context, userName string) ([]
 	return users, nil
 }
 
-func (r *userRepository) FindBasicLists(ctx context.Context, userSearch model.UserBasic, page *model.Page[model.UserBasic]) error {
+// 按用户名或用户 id 搜索，屏蔽了搜索者的用户不会出现在结果中
+func (r *userRepository) FindBasicLists(ctx context.Context, searcherId int64, userSearch model.UserBasic, page *model.Page[model.UserBasic]) error {
 	var userBasic model.UserBasic
 	selectSql := `
 		select user_id,user_name
 		from im_users 
 		where deleted = ? and (user_name like ? or user_id = ?)
+			and not exists (select 1 from im_user_block iub where iub.blocker_id = im_users.user_id and iub.blocked_id = ?)
 		order by user_id
 		limit ? offset ?
 	`
@@ -269,6 +271,7 @@ func (r *userRepository) FindBasicLists(ctx context.Context, userSearch model.Us
 		0,
 		fmt.Sprintf("%%%s%%", userSearch.UserName),
 		userSearch.UserId,
+		searcherId,
 		page.PageSize,
 		(page.CurrentPage-1)*page.PageSize,
 	)
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/repository"
+)
+
+// 资源归属校验，operatorId 为当前会话的登录用户
+type Authorizer interface {
+	AuthorizeUser(operatorId, userId int64) error
+	AuthorizeSingle(ctx context.Context, operatorId, singleId int64) error
+	AuthorizeGroup(ctx context.Context, operatorId, groupId int64, action string) error
+}
+
+type authorizer struct {
+	singleRepo repository.SingleRepository
+	groupRepo  repository.GroupRepository
+}
+
+func NewAuthorizer(singleRepo repository.SingleRepository, groupRepo repository.GroupRepository) Authorizer {
+	return &authorizer{
+		singleRepo: singleRepo,
+		groupRepo:  groupRepo,
+	}
+}
+
+func permissionDenied() error {
+	return &model.DError{
+		Code:    constant.ErrPermissionDenied,
+		Message: constant.MsgPermissionDenied,
+	}
+}
+
+// 用户只能修改或注销自己
+func (a *authorizer) AuthorizeUser(operatorId, userId int64) error {
+	if operatorId != userId {
+		return permissionDenied()
+	}
+	return nil
+}
+
+// 只有单聊双方可以修改该单聊的设置
+func (a *authorizer) AuthorizeSingle(ctx context.Context, operatorId, singleId int64) error {
+	single := &model.Single{
+		SingleId: singleId,
+	}
+	err := a.singleRepo.FindParticipants(ctx, single)
+	if err != nil {
+		return permissionDenied()
+	}
+	if operatorId != single.InviterId && operatorId != single.InviteeId {
+		return permissionDenied()
+	}
+	return nil
+}
+
+// 按群权限矩阵校验操作者在该群中的角色
+func (a *authorizer) AuthorizeGroup(ctx context.Context, operatorId, groupId int64, action string) error {
+	groupToUser := &model.GroupToUser{
+		GroupId: groupId,
+		UserId:  operatorId,
+	}
+	err := a.groupRepo.FindGroupToUser(ctx, groupToUser)
+	if err != nil {
+		return permissionDenied()
+	}
+	if !groupAllowed(groupToUser.UserRole, action) {
+		return permissionDenied()
+	}
+	return nil
+}
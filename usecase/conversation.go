@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/repository"
+)
+
+// 文本消息预览保留的最大字符数
+const (
+	CONVERSATION_PREVIEW_LENGTH = 50
+)
+
+// 非文本消息的预览文字
+var previewTexts = map[string]string{
+	MESSAGE_TYPE_IMAGE: "[图片]",
+	MESSAGE_TYPE_AUDIO: "[语音]",
+	MESSAGE_TYPE_VIDEO: "[视频]",
+	MESSAGE_TYPE_LINK:  "[链接]",
+}
+
+type ConversationUsecase interface {
+	GetLogger() log.Logger
+	Conversations(operatorId, tagId int64) ([]*model.Conversation, error)
+	Pin(operatorId int64, dialogType int, dialogId int64, pinned bool) error
+}
+
+type conversationUsecase struct {
+	repo        repository.ConversationRepository
+	messageRepo repository.MessageRepository
+	groupRepo   repository.GroupRepository
+	logger      log.Logger
+	c           context.Context
+	t           time.Duration
+}
+
+func NewConversationUsecase(repo repository.ConversationRepository, messageRepo repository.MessageRepository, groupRepo repository.GroupRepository) ConversationUsecase {
+	return &conversationUsecase{
+		repo:        repo,
+		messageRepo: messageRepo,
+		groupRepo:   groupRepo,
+		logger:      repo.GetLogger(),
+		c:           context.Background(),
+		t:           5 * time.Second,
+	}
+}
+
+func (u *conversationUsecase) GetLogger() log.Logger {
+	return u.logger
+}
+
+// 合并单聊与群聊会话，置顶的在前，其余按最后活跃时间递减
+func (u *conversationUsecase) Conversations(operatorId, tagId int64) ([]*model.Conversation, error) {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	singles, err := u.repo.FindSingleConversations(ctx, operatorId, SINGLE_STATUS_ACCEPTED, tagId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrConversationFail,
+			Message: constant.MsgConversationFail,
+		}
+	}
+	groups, err := u.repo.FindGroupConversations(ctx, operatorId, tagId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrConversationFail,
+			Message: constant.MsgConversationFail,
+		}
+	}
+	conversations := append(singles, groups...)
+	// 未读数与置顶状态保存在 redis，读取失败时仓储已记录日志，列表照常返回(未读数为 0、不置顶)
+	u.repo.FindUnread(ctx, operatorId, conversations)
+	u.repo.FindPinned(ctx, operatorId, conversations)
+	for _, conversation := range conversations {
+		if conversation.LastMessage != nil {
+			decodeContent(conversation.LastMessage)
+			conversation.Preview = preview(conversation.LastMessage)
+		}
+	}
+	sortConversations(conversations)
+	return conversations, nil
+}
+
+// 只能置顶自己已建立的单聊或所在的群
+func (u *conversationUsecase) Pin(operatorId int64, dialogType int, dialogId int64, pinned bool) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	switch dialogType {
+	case DIALOG_SINGLE:
+		_, err := u.messageRepo.FindSingleId(ctx, operatorId, dialogId)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageNotContact,
+				Message: constant.MsgMessageNotContact,
+			}
+		}
+	case DIALOG_GROUP:
+		groupToUser := &model.GroupToUser{
+			GroupId: dialogId,
+			UserId:  operatorId,
+		}
+		err := u.groupRepo.FindGroupToUser(ctx, groupToUser)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageNotMember,
+				Message: constant.MsgMessageNotMember,
+			}
+		}
+	default:
+		return &model.DError{
+			Code:    constant.ErrArgument,
+			Message: constant.MsgArgumentErr,
+		}
+	}
+	err := u.repo.UpdatePinned(ctx, operatorId, dialogType, dialogId, pinned)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrConversationFail,
+			Message: constant.MsgConversationFail,
+		}
+	}
+	return nil
+}
+
+// 文本消息截取前若干个字符，其余类型显示类型提示，撤回的消息显示撤回提示
+func preview(message *model.MessageItem) string {
+	if message.Status == MESSAGE_STATUS_WITHDRAWN {
+		return "[消息已撤回]"
+	}
+	if message.Type != MESSAGE_TYPE_TEXT {
+		return previewTexts[message.Type]
+	}
+	text := []rune(message.Text)
+	if len(text) > CONVERSATION_PREVIEW_LENGTH {
+		return string(text[:CONVERSATION_PREVIEW_LENGTH]) + "..."
+	}
+	return message.Text
+}
+
+func sortConversations(conversations []*model.Conversation) {
+	sort.SliceStable(conversations, func(i, j int) bool {
+		a, b := conversations[i], conversations[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if !a.LastTime.Equal(b.LastTime) {
+			return a.LastTime.After(b.LastTime)
+		}
+		if a.DialogType != b.DialogType {
+			return a.DialogType < b.DialogType
+		}
+		return a.DialogId < b.DialogId
+	})
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wendisx/gorchat/model"
+)
+
+func TestPreview(t *testing.T) {
+	t.Parallel()
+	long := strings.Repeat("消", CONVERSATION_PREVIEW_LENGTH+1)
+	cases := []struct {
+		message *model.MessageItem
+		expect  string
+	}{
+		{&model.MessageItem{Type: MESSAGE_TYPE_TEXT, Text: "hello", Status: MESSAGE_STATUS_UNREAD}, "hello"},
+		{&model.MessageItem{Type: MESSAGE_TYPE_TEXT, Text: long, Status: MESSAGE_STATUS_READ}, strings.Repeat("消", CONVERSATION_PREVIEW_LENGTH) + "..."},
+		{&model.MessageItem{Type: MESSAGE_TYPE_IMAGE, Status: MESSAGE_STATUS_UNREAD}, "[图片]"},
+		{&model.MessageItem{Type: MESSAGE_TYPE_TEXT, Status: MESSAGE_STATUS_WITHDRAWN}, "[消息已撤回]"},
+	}
+	for _, c := range cases {
+		if got := preview(c.message); got != c.expect {
+			t.Errorf("-- preview %q expect %q", got, c.expect)
+		}
+	}
+}
+
+func TestSortConversations(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	conversations := []*model.Conversation{
+		{DialogType: DIALOG_SINGLE, DialogId: 1, LastTime: now.Add(-time.Hour)},
+		{DialogType: DIALOG_GROUP, DialogId: 2, LastTime: now},
+		{DialogType: DIALOG_SINGLE, DialogId: 3, LastTime: now.Add(-2 * time.Hour), Pinned: true},
+	}
+	sortConversations(conversations)
+	expect := []int64{3, 2, 1}
+	for i, conversation := range conversations {
+		if conversation.DialogId != expect[i] {
+			t.Errorf("-- position %d dialog %d expect %d", i, conversation.DialogId, expect[i])
+		}
+	}
+}
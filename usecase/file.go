@@ -0,0 +1,323 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/internal/storage"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/repository"
+)
+
+type FileUsecase interface {
+	GetLogger() log.Logger
+	Upload(r io.Reader, size int64) (*storage.Blob, error)
+	Open(userId int64, blobId string) (io.ReadSeekCloser, *storage.Blob, error)
+	InitUpload(upload *model.Upload) (time.Time, error)
+	UploadPart(uploadId string, part int, r io.Reader) (*model.UploadPart, error)
+	GetUpload(uploadId string) (*model.Upload, []*model.UploadPart, error)
+	CompleteUpload(uploadId string) (*storage.Blob, error)
+}
+
+type fileUsecase struct {
+	store      storage.BlobStore
+	parts      storage.PartStore
+	uploadRepo repository.UploadRepository
+	blobRepo   repository.BlobRepository
+	maxSize    int64         // 单次请求(整个文件或单个分片)的大小上限
+	blobSize   int64         // 分片上传拼接后整个文件的大小上限
+	ttl        time.Duration // 分片上传任务的空闲过期时间
+	logger     log.Logger
+	c          context.Context
+	t          time.Duration
+}
+
+func NewFileUsecase(store storage.BlobStore, parts storage.PartStore, uploadRepo repository.UploadRepository, blobRepo repository.BlobRepository, maxSize, blobSize int64, ttl time.Duration) FileUsecase {
+	return &fileUsecase{
+		store:      store,
+		parts:      parts,
+		uploadRepo: uploadRepo,
+		blobRepo:   blobRepo,
+		maxSize:    maxSize,
+		blobSize:   blobSize,
+		ttl:        ttl,
+		logger:     uploadRepo.GetLogger(),
+		c:          context.Background(),
+		t:          30 * time.Second,
+	}
+}
+
+func (u *fileUsecase) GetLogger() log.Logger {
+	return u.logger
+}
+
+func (u *fileUsecase) Upload(r io.Reader, size int64) (*storage.Blob, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	if size > u.maxSize {
+		return nil, &model.DError{
+			Code:    constant.ErrFileTooLarge,
+			Message: constant.MsgFileTooLarge,
+		}
+	}
+	blob, err := u.store.Put(ctx, r)
+	if errors.Is(err, storage.ErrBlobTooLarge) {
+		return nil, &model.DError{
+			Code:    constant.ErrFileTooLarge,
+			Message: constant.MsgFileTooLarge,
+		}
+	}
+	if err != nil {
+		log.Error(
+			u.logger,
+			"blob put",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrFileUploadFail,
+			Message: constant.MsgFileUploadFail,
+		}
+	}
+	return blob, nil
+}
+
+// 只有能看到附件的用户才能下载，无权访问与附件不存在返回相同的错误
+func (u *fileUsecase) Open(userId int64, blobId string) (io.ReadSeekCloser, *storage.Blob, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	readable, err := u.blobRepo.FindReadable(ctx, userId, blobId)
+	if err != nil || !readable {
+		return nil, nil, &model.DError{
+			Code:    constant.ErrFileNotFound,
+			Message: constant.MsgFileNotFound,
+		}
+	}
+	reader, blob, err := u.store.Open(ctx, blobId)
+	if err != nil {
+		return nil, nil, &model.DError{
+			Code:    constant.ErrFileNotFound,
+			Message: constant.MsgFileNotFound,
+		}
+	}
+	return reader, blob, nil
+}
+
+// 创建分片上传任务，返回任务的过期时间，每收到一个分片过期时间顺延
+func (u *fileUsecase) InitUpload(upload *model.Upload) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	if upload.Size > u.blobSize {
+		return time.Time{}, &model.DError{
+			Code:    constant.ErrFileTooLarge,
+			Message: constant.MsgFileTooLarge,
+		}
+	}
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return time.Time{}, &model.DError{
+			Code:    constant.ErrUploadInitFail,
+			Message: constant.MsgUploadInitFail,
+		}
+	}
+	upload.UploadId = hex.EncodeToString(id)
+	upload.CreatedTime = time.Now()
+	err = u.uploadRepo.InsertUpload(ctx, upload, u.ttl)
+	if err != nil {
+		return time.Time{}, &model.DError{
+			Code:    constant.ErrUploadInitFail,
+			Message: constant.MsgUploadInitFail,
+		}
+	}
+	return upload.CreatedTime.Add(u.ttl), nil
+}
+
+// 上传第 part 个分片，分片序号从 1 开始，重复上传会覆盖
+// 分片大小不能超过单次请求上限，也不能使已收到的分片总大小超过声明的文件大小
+func (u *fileUsecase) UploadPart(uploadId string, part int, r io.Reader) (*model.UploadPart, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	upload, err := u.findUpload(ctx, uploadId)
+	if err != nil {
+		return nil, err
+	}
+	if part < 1 || part > upload.PartCount {
+		return nil, &model.DError{
+			Code:    constant.ErrUploadPartFail,
+			Message: constant.MsgUploadPartFail,
+		}
+	}
+	parts, err := u.uploadRepo.FindParts(ctx, uploadId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrUploadPartFail,
+			Message: constant.MsgUploadPartFail,
+		}
+	}
+	remaining := upload.Size
+	for _, received := range parts {
+		// 重复上传的分片会被覆盖，不计入已收到的大小
+		if received.Part != part {
+			remaining -= received.Size
+		}
+	}
+	limit := min(u.maxSize, remaining)
+	if limit <= 0 {
+		return nil, &model.DError{
+			Code:    constant.ErrFileTooLarge,
+			Message: constant.MsgFileTooLarge,
+		}
+	}
+	size, err := u.parts.WritePart(uploadId, part, r, limit)
+	if errors.Is(err, storage.ErrPartTooLarge) {
+		return nil, &model.DError{
+			Code:    constant.ErrFileTooLarge,
+			Message: constant.MsgFileTooLarge,
+		}
+	}
+	if err != nil {
+		log.Error(
+			u.logger,
+			"part write",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrUploadPartFail,
+			Message: constant.MsgUploadPartFail,
+		}
+	}
+	uploadPart := &model.UploadPart{
+		Part: part,
+		Size: size,
+	}
+	err = u.uploadRepo.InsertPart(ctx, uploadId, uploadPart, u.ttl)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrUploadPartFail,
+			Message: constant.MsgUploadPartFail,
+		}
+	}
+	return uploadPart, nil
+}
+
+// 查询任务以及已收到的分片，用于断点续传
+func (u *fileUsecase) GetUpload(uploadId string) (*model.Upload, []*model.UploadPart, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	upload, err := u.findUpload(ctx, uploadId)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts, err := u.uploadRepo.FindParts(ctx, uploadId)
+	if err != nil {
+		return nil, nil, &model.DError{
+			Code:    constant.ErrUploadNotFound,
+			Message: constant.MsgUploadNotFound,
+		}
+	}
+	return upload, parts, nil
+}
+
+// 全部分片到齐且总大小与声明一致时按序拼接写入附件存储，随后清理任务
+func (u *fileUsecase) CompleteUpload(uploadId string) (*storage.Blob, error) {
+	upload, parts, err := u.GetUpload(uploadId)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for i, part := range parts {
+		if part.Part != i+1 {
+			break
+		}
+		size += part.Size
+	}
+	if len(parts) != upload.PartCount || size != upload.Size {
+		return nil, &model.DError{
+			Code:    constant.ErrUploadNotDone,
+			Message: constant.MsgUploadNotDone,
+		}
+	}
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	reader, err := u.parts.Open(uploadId, upload.PartCount)
+	if err != nil {
+		log.Error(
+			u.logger,
+			"part open",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrUploadNotDone,
+			Message: constant.MsgUploadNotDone,
+		}
+	}
+	defer reader.Close()
+	blob, err := u.store.Put(ctx, reader)
+	if errors.Is(err, storage.ErrBlobTooLarge) {
+		return nil, &model.DError{
+			Code:    constant.ErrFileTooLarge,
+			Message: constant.MsgFileTooLarge,
+		}
+	}
+	if err != nil {
+		log.Error(
+			u.logger,
+			"blob put",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+		return nil, &model.DError{
+			Code:    constant.ErrFileUploadFail,
+			Message: constant.MsgFileUploadFail,
+		}
+	}
+	// 附件已经落盘，清理失败的残留由过期机制兜底
+	u.uploadRepo.DeleteUpload(ctx, uploadId)
+	u.parts.Remove(uploadId)
+	return blob, nil
+}
+
+func (u *fileUsecase) findUpload(ctx context.Context, uploadId string) (*model.Upload, error) {
+	if !storage.ValidUploadId(uploadId) {
+		return nil, &model.DError{
+			Code:    constant.ErrUploadNotFound,
+			Message: constant.MsgUploadNotFound,
+		}
+	}
+	upload, err := u.uploadRepo.FindUpload(ctx, uploadId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrUploadNotFound,
+			Message: constant.MsgUploadNotFound,
+		}
+	}
+	return upload, nil
+}
+
+// 校验引用的附件已经上传，空值表示未设置
+func checkBlob(ctx context.Context, store storage.BlobStore, blobId string) error {
+	if blobId == "" {
+		return nil
+	}
+	ok, err := store.Exists(ctx, blobId)
+	if err != nil || !ok {
+		return &model.DError{
+			Code:    constant.ErrFileNotFound,
+			Message: constant.MsgFileNotFound,
+		}
+	}
+	return nil
+}
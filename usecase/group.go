@@ -2,12 +2,18 @@ package usecase
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"time"
 
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/internal/storage"
+	"github.com/wendisx/gorchat/internal/ws"
 	"github.com/wendisx/gorchat/model"
 	"github.com/wendisx/gorchat/repository"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -16,33 +22,79 @@ const (
 	ROLE_JOINER = "joiner"
 )
 
+// 入群方式
+const (
+	JOIN_POLICY_OPEN     = "open"     // 直接加入
+	JOIN_POLICY_PASSWORD = "password" // 校验群密码，未设置密码时直接加入
+	JOIN_POLICY_APPROVAL = "approval" // 提交申请，由群主或管理员审批
+	JOIN_POLICY_INVITE   = "invite"   // 只能通过邀请码加入
+)
+
+// 入群申请状态
+const (
+	REQUEST_STATUS_PENDING = iota
+	REQUEST_STATUS_APPROVED
+	REQUEST_STATUS_REJECTED
+)
+
 type GroupUsecase interface {
 	GetLogger() log.Logger
 	GroupCreate(groupBasic *model.GroupBasic) error
-	GroupJoin(groupToUser *model.GroupToUser) error
-	GroupUpdate(group *model.Group) error
-	GroupUpdateUser(groupToUser *model.GroupToUser) error
-	GroupDelete(groupId int64) error
-	GroupDeleteUser(groupId, userId int64) error
+	GroupJoin(groupToUser *model.GroupToUser, groupPassword, message string) (bool, error)
+	VerifyGroupPassword(groupId int64, groupPassword string) error
+	GroupUpdate(operatorId int64, group *model.Group) error
+	GroupUpdateUser(operatorId int64, groupToUser *model.GroupToUser) error
+	GroupDelete(operatorId, groupId int64) error
+	GroupDeleteUser(operatorId, groupId, userId int64) error
+	GroupTransfer(operatorId, groupId, successorId int64, demoteRole string) error
 	GroupUserDetail(groupToUser *model.GroupToUser) error
 	GroupSearchUser(groupUser *model.GroupUser, page *model.Page[*model.GroupToUserItem]) error
 	GroupSearch(groupItem *model.GroupItem, page *model.Page[*model.GroupItem]) error
 	GroupAllUsers(groupId int64, page *model.Page[*model.GroupToUserItem]) error
+	GroupPermissions(operatorId, groupId int64) (*model.GroupPermissionRes, error)
+	GroupCreateInvite(operatorId int64, invite *model.GroupInvite, expire time.Duration) error
+	GroupInvites(operatorId, groupId int64) ([]*model.GroupInvite, error)
+	GroupRevokeInvite(operatorId int64, code string) error
+	GroupJoinByCode(groupToUser *model.GroupToUser, code string) error
+	GroupSetJoinPolicy(operatorId, groupId int64, joinPolicy string) error
+	GroupRequests(operatorId, groupId int64, page *model.Page[*model.JoinRequest]) error
+	GroupHandleRequest(operatorId, requestId int64, approve bool) error
+	GroupBan(operatorId int64, groupBan *model.GroupBan, ttl time.Duration) error
+	GroupUnban(operatorId, groupId, userId int64) error
+	GroupBans(operatorId, groupId int64, page *model.Page[*model.GroupBan]) error
+	GroupMute(operatorId, groupId, userId int64, duration time.Duration) error
+	GroupAnnounce(operatorId int64, announcement *model.GroupAnnouncement) error
+	GroupUpdateAnnouncement(operatorId int64, announcement *model.GroupAnnouncement) error
+	GroupDeleteAnnouncement(operatorId, announcementId int64) error
+	GroupAnnouncements(operatorId, groupId int64, page *model.Page[*model.GroupAnnouncement]) error
+	GroupAckAnnouncement(operatorId, announcementId int64) error
 }
 
 type groupUsecase struct {
-	repo   repository.GroupRepository
-	logger log.Logger
-	c      context.Context
-	t      time.Duration
+	repo         repository.GroupRepository
+	inviteRepo   repository.InviteRepository
+	requestRepo  repository.JoinRequestRepository
+	announceRepo repository.AnnouncementRepository
+	store        storage.BlobStore
+	hub          ws.Hub
+	auth         Authorizer
+	logger       log.Logger
+	c            context.Context
+	t            time.Duration
 }
 
-func NewGroupUsecase(repo repository.GroupRepository) GroupUsecase {
+func NewGroupUsecase(repo repository.GroupRepository, inviteRepo repository.InviteRepository, requestRepo repository.JoinRequestRepository, announceRepo repository.AnnouncementRepository, store storage.BlobStore, hub ws.Hub, auth Authorizer) GroupUsecase {
 	return &groupUsecase{
-		repo:   repo,
-		logger: repo.GetLogger(),
-		c:      context.Background(),
-		t:      5 * time.Second,
+		repo:         repo,
+		inviteRepo:   inviteRepo,
+		requestRepo:  requestRepo,
+		announceRepo: announceRepo,
+		store:        store,
+		hub:          hub,
+		auth:         auth,
+		logger:       repo.GetLogger(),
+		c:            context.Background(),
+		t:            5 * time.Second,
 	}
 }
 
@@ -53,9 +105,16 @@ func (u *groupUsecase) GetLogger() log.Logger {
 func (u *groupUsecase) GroupCreate(groupBasic *model.GroupBasic) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
+	hashPassword, err := hashGroupPassword(groupBasic.GroupPassword)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupCreateFail,
+			Message: constant.MsgGroupCreateFail,
+		}
+	}
 	group := &model.Group{
 		GroupName:     groupBasic.GroupName,
-		GroupPassword: groupBasic.GroupPassword,
+		GroupPassword: hashPassword,
 		GroupMaxSize:  groupBasic.GroupMaxSize,
 	}
 	groupId, err := u.repo.InsertOneGroup(ctx, group)
@@ -98,23 +157,176 @@ func (u *groupUsecase) GroupCreate(groupBasic *model.GroupBasic) error {
 	return nil
 }
 
-func (u *groupUsecase) GroupJoin(groupToUser *model.GroupToUser) error {
+// 按群的入群方式加入，需要审批时只提交申请并返回 true
+func (u *groupUsecase) GroupJoin(groupToUser *model.GroupToUser, groupPassword, message string) (bool, error) {
 	ctx, cancel := context.WithTimeout(u.c, u.t)
 	defer cancel()
-	err := u.repo.InsertUserInGroup(ctx, groupToUser)
+	joinPolicy, err := u.repo.FindGroupJoinPolicy(ctx, groupToUser.GroupId)
+	if err != nil {
+		return false, &model.DError{
+			Code:    constant.ErrGroupJoinFail,
+			Message: constant.MsgGroupJoinFail,
+		}
+	}
+	switch joinPolicy {
+	case JOIN_POLICY_OPEN:
+	case JOIN_POLICY_PASSWORD:
+		err = u.verifyGroupPassword(ctx, groupToUser.GroupId, groupPassword)
+		if err != nil {
+			return false, err
+		}
+	case JOIN_POLICY_APPROVAL:
+		return true, u.applyGroup(ctx, groupToUser, message)
+	default:
+		return false, &model.DError{
+			Code:    constant.ErrGroupJoinPolicy,
+			Message: constant.MsgGroupJoinPolicy,
+		}
+	}
+	err = u.repo.UpsertUserInGroup(ctx, groupToUser)
+	if joinRejected(err) {
+		return false, err
+	}
+	if err != nil {
+		return false, &model.DError{
+			Code:    constant.ErrGroupJoinFail,
+			Message: constant.MsgGroupJoinFail,
+		}
+	}
+	return false, nil
+}
+
+// 提交入群申请，已在群内时不再重复申请
+func (u *groupUsecase) applyGroup(ctx context.Context, groupToUser *model.GroupToUser, message string) error {
+	member := &model.GroupToUser{
+		GroupId: groupToUser.GroupId,
+		UserId:  groupToUser.UserId,
+	}
+	if u.repo.FindGroupToUser(ctx, member) == nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAlreadyMember,
+			Message: constant.MsgGroupAlreadyMember,
+		}
+	}
+	banned, err := u.repo.FindGroupBanned(ctx, groupToUser.GroupId, groupToUser.UserId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupRequestFail,
+			Message: constant.MsgGroupRequestFail,
+		}
+	}
+	if banned {
+		return &model.DError{
+			Code:    constant.ErrGroupBanned,
+			Message: constant.MsgGroupBanned,
+		}
+	}
+	joinRequest := &model.JoinRequest{
+		GroupId:      groupToUser.GroupId,
+		UserId:       groupToUser.UserId,
+		UserNickname: groupToUser.UserNickname,
+		Message:      message,
+		Status:       REQUEST_STATUS_PENDING,
+	}
+	err = u.requestRepo.UpsertJoinRequest(ctx, joinRequest)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupRequestFail,
+			Message: constant.MsgGroupRequestFail,
+		}
+	}
+	return nil
+}
+
+func (u *groupUsecase) VerifyGroupPassword(groupId int64, groupPassword string) error {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	return u.verifyGroupPassword(ctx, groupId, groupPassword)
+}
+
+func (u *groupUsecase) verifyGroupPassword(ctx context.Context, groupId int64, groupPassword string) error {
+	var hashPassword string
+	err := u.repo.FindGroupPassword(ctx, groupId, &hashPassword)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrGroupJoinFail,
 			Message: constant.MsgGroupJoinFail,
 		}
 	}
+	// 未设置密码的群可以直接加入
+	if hashPassword == "" {
+		return nil
+	}
+	// 迁移前保存的明文密码不是 bcrypt 哈希，按常量时间比较，通过后替换为哈希
+	if _, err = bcrypt.Cost([]byte(hashPassword)); err != nil {
+		if subtle.ConstantTimeCompare([]byte(hashPassword), []byte(groupPassword)) != 1 {
+			return &model.DError{
+				Code:    constant.ErrGroupPasswordFail,
+				Message: constant.MsgGroupPasswordFail,
+			}
+		}
+		u.rehashGroupPassword(ctx, groupId, hashPassword)
+		return nil
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(groupPassword))
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupPasswordFail,
+			Message: constant.MsgGroupPasswordFail,
+		}
+	}
 	return nil
 }
 
-func (u *groupUsecase) GroupUpdate(group *model.Group) error {
+// 替换失败不影响本次验证，下次验证时再替换
+func (u *groupUsecase) rehashGroupPassword(ctx context.Context, groupId int64, groupPassword string) {
+	hashPassword, err := hashGroupPassword(groupPassword)
+	if err == nil {
+		err = u.repo.UpdateGroupPassword(ctx, groupId, groupPassword, hashPassword)
+	}
+	if err != nil {
+		log.Warn(
+			u.logger,
+			"group password rehash",
+			map[string]any{
+				"error": err.Error(),
+			},
+		)
+	}
+}
+
+// 群密码与用户密码一样只保存 bcrypt 哈希，空密码表示不设密码
+func hashGroupPassword(groupPassword string) (string, error) {
+	if groupPassword == "" {
+		return "", nil
+	}
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(groupPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashPassword), nil
+}
+
+func (u *groupUsecase) GroupUpdate(operatorId int64, group *model.Group) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
-	err := u.repo.UpdateGroup(ctx, group)
+	err := u.auth.AuthorizeGroup(ctx, operatorId, group.GroupId, ACTION_UPDATE_GROUP)
+	if err != nil {
+		return err
+	}
+	// 群头像为附件存储中的 blob id
+	err = checkBlob(ctx, u.store, group.GroupAvatar)
+	if err != nil {
+		return err
+	}
+	group.GroupPassword, err = hashGroupPassword(group.GroupPassword)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupUpdateFail,
+			Message: constant.MsgGroupUpdateFail,
+		}
+	}
+	err = u.repo.UpdateGroup(ctx, group)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrGroupUpdateFail,
@@ -124,7 +336,7 @@ func (u *groupUsecase) GroupUpdate(group *model.Group) error {
 	return nil
 }
 
-func (u *groupUsecase) GroupUpdateUser(groupToUser *model.GroupToUser) error {
+func (u *groupUsecase) GroupUpdateUser(operatorId int64, groupToUser *model.GroupToUser) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
 	tmpGroupToUser := &model.GroupToUser{
@@ -138,6 +350,10 @@ func (u *groupUsecase) GroupUpdateUser(groupToUser *model.GroupToUser) error {
 			Message: constant.MsgGroupUpdateUserFail,
 		}
 	}
+	err = u.authorizeUpdateUser(ctx, operatorId, tmpGroupToUser, groupToUser)
+	if err != nil {
+		return err
+	}
 	if groupToUser.IsSetDisturb {
 		tmpGroupToUser.UserDisturb = groupToUser.UserDisturb
 	} else if groupToUser.IsSetRole {
@@ -167,10 +383,47 @@ func (u *groupUsecase) GroupUpdateUser(groupToUser *model.GroupToUser) error {
 	return nil
 }
 
-func (u *groupUsecase) GroupDelete(groupId int64) error {
+// 按照与赋值相同的优先级判断本次设置对应的群操作
+func (u *groupUsecase) authorizeUpdateUser(ctx context.Context, operatorId int64, target, groupToUser *model.GroupToUser) error {
+	switch {
+	case groupToUser.IsSetDisturb:
+	case groupToUser.IsSetRole:
+		action, ok := roleAction(target.UserRole, u.roleName(ctx, groupToUser.UserRoleId))
+		if !ok || operatorId == target.UserId {
+			return permissionDenied()
+		}
+		return u.auth.AuthorizeGroup(ctx, operatorId, target.GroupId, action)
+	case groupToUser.IsSetGroupNickname:
+	case groupToUser.IsSetUserNickname:
+		if operatorId != target.UserId {
+			return u.auth.AuthorizeGroup(ctx, operatorId, target.GroupId, ACTION_SET_MEMBER_NICKNAME)
+		}
+	}
+	// 免打扰和群备注只能由本人设置
+	if operatorId != target.UserId {
+		return permissionDenied()
+	}
+	return nil
+}
+
+func (u *groupUsecase) roleName(ctx context.Context, roleId int) string {
+	for _, role := range []string{ROLE_OWNER, ROLE_ADMIN, ROLE_JOINER} {
+		id, err := u.repo.FindUserRoleId(ctx, role)
+		if err == nil && id == roleId {
+			return role
+		}
+	}
+	return ""
+}
+
+func (u *groupUsecase) GroupDelete(operatorId, groupId int64) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
-	err := u.repo.DeleteGroup(ctx, groupId)
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_DELETE_GROUP)
+	if err != nil {
+		return err
+	}
+	err = u.repo.DeleteGroup(ctx, groupId)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrGroupDeleteFail,
@@ -180,10 +433,39 @@ func (u *groupUsecase) GroupDelete(groupId int64) error {
 	return nil
 }
 
-func (u *groupUsecase) GroupDeleteUser(groupId, userId int64) error {
+func (u *groupUsecase) GroupDeleteUser(operatorId, groupId, userId int64) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
-	err := u.repo.DeleteGroupToUser(ctx, groupId, userId)
+	target := &model.GroupToUser{
+		GroupId: groupId,
+		UserId:  userId,
+	}
+	err := u.repo.FindGroupToUser(ctx, target)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupDeleteUserFail,
+			Message: constant.MsgGroupDeleteUserFail,
+		}
+	}
+	if operatorId == userId {
+		// 群主退群前先移交群主
+		if target.UserRole == ROLE_OWNER {
+			err = handOverGroup(ctx, u.repo, groupId, userId)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		action, ok := kickAction(target.UserRole)
+		if !ok {
+			return permissionDenied()
+		}
+		err = u.auth.AuthorizeGroup(ctx, operatorId, groupId, action)
+		if err != nil {
+			return err
+		}
+	}
+	err = u.repo.DeleteGroupToUser(ctx, groupId, userId)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrGroupDeleteUserFail,
@@ -193,6 +475,105 @@ func (u *groupUsecase) GroupDeleteUser(groupId, userId int64) error {
 	return nil
 }
 
+func (u *groupUsecase) GroupTransfer(operatorId, groupId, successorId int64, demoteRole string) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_TRANSFER_OWNER)
+	if err != nil {
+		return err
+	}
+	if demoteRole == "" {
+		demoteRole = ROLE_ADMIN
+	}
+	if demoteRole != ROLE_ADMIN && demoteRole != ROLE_JOINER || successorId == operatorId {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	return transferOwner(ctx, u.repo, groupId, operatorId, successorId, demoteRole)
+}
+
+func transferOwner(ctx context.Context, repo repository.GroupRepository, groupId, ownerId, successorId int64, demoteRole string) error {
+	ownerRoleId, err := repo.FindUserRoleId(ctx, ROLE_OWNER)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	demoteRoleId, err := repo.FindUserRoleId(ctx, demoteRole)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	err = repo.UpdateGroupOwner(ctx, groupId, ownerId, successorId, ownerRoleId, demoteRoleId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	return nil
+}
+
+// 群主离开时自动移交: 最早入群的管理员，其次最早入群的成员，没有其他成员时解散群聊
+func handOverGroup(ctx context.Context, repo repository.GroupRepository, groupId, ownerId int64) error {
+	adminRoleId, err := repo.FindUserRoleId(ctx, ROLE_ADMIN)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	successorId, err := repo.FindGroupSuccessor(ctx, groupId, ownerId, adminRoleId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	if successorId == 0 {
+		err = repo.DeleteGroup(ctx, groupId)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrGroupDeleteFail,
+				Message: constant.MsgGroupDeleteFail,
+			}
+		}
+		return nil
+	}
+	return transferOwner(ctx, repo, groupId, ownerId, successorId, ROLE_JOINER)
+}
+
+// 移交用户名下的所有群
+func handOverGroups(ctx context.Context, repo repository.GroupRepository, userId int64) error {
+	ownerRoleId, err := repo.FindUserRoleId(ctx, ROLE_OWNER)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	groupIds, err := repo.FindOwnedGroupIds(ctx, userId, ownerRoleId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupTransferFail,
+			Message: constant.MsgGroupTransferFail,
+		}
+	}
+	for _, groupId := range groupIds {
+		err = handOverGroup(ctx, repo, groupId, userId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 成员的群设置，附带当前的置顶公告
 func (u *groupUsecase) GroupUserDetail(groupToUser *model.GroupToUser) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
@@ -203,6 +584,13 @@ func (u *groupUsecase) GroupUserDetail(groupToUser *model.GroupToUser) error {
 			Message: constant.MsgGroupGetDetailFail,
 		}
 	}
+	groupToUser.Announcement, err = u.announceRepo.FindPinnedAnnouncement(ctx, groupToUser.GroupId, groupToUser.UserId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupGetDetailFail,
+			Message: constant.MsgGroupGetDetailFail,
+		}
+	}
 	return nil
 }
 
@@ -244,3 +632,539 @@ func (u *groupUsecase) GroupAllUsers(groupId int64, page *model.Page[*model.Grou
 	}
 	return nil
 }
+
+func (u *groupUsecase) GroupPermissions(operatorId, groupId int64) (*model.GroupPermissionRes, error) {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	groupPermissionRes := &model.GroupPermissionRes{
+		GroupId:     groupId,
+		Permissions: GroupPermissions(),
+	}
+	if groupId == 0 {
+		return groupPermissionRes, nil
+	}
+	groupToUser := &model.GroupToUser{
+		GroupId: groupId,
+		UserId:  operatorId,
+	}
+	err := u.repo.FindGroupToUser(ctx, groupToUser)
+	if err != nil {
+		return nil, permissionDenied()
+	}
+	groupPermissionRes.UserRole = groupToUser.UserRole
+	groupPermissionRes.Actions = groupActions(groupToUser.UserRole)
+	return groupPermissionRes, nil
+}
+
+// 创建邀请码，邀请成为管理员时还需要设置管理员的权限
+func (u *groupUsecase) GroupCreateInvite(operatorId int64, invite *model.GroupInvite, expire time.Duration) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	if invite.Role == "" {
+		invite.Role = ROLE_JOINER
+	}
+	if invite.Role != ROLE_JOINER && invite.Role != ROLE_ADMIN {
+		return &model.DError{
+			Code:    constant.ErrGroupInviteFail,
+			Message: constant.MsgGroupInviteFail,
+		}
+	}
+	err := u.auth.AuthorizeGroup(ctx, operatorId, invite.GroupId, ACTION_MANAGE_INVITE)
+	if err != nil {
+		return err
+	}
+	if invite.Role == ROLE_ADMIN {
+		err = u.auth.AuthorizeGroup(ctx, operatorId, invite.GroupId, ACTION_PROMOTE_ADMIN)
+		if err != nil {
+			return err
+		}
+	}
+	code := make([]byte, 6)
+	_, err = rand.Read(code)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupInviteFail,
+			Message: constant.MsgGroupInviteFail,
+		}
+	}
+	invite.Code = hex.EncodeToString(code)
+	invite.CreatorId = operatorId
+	invite.CreatedTime = time.Now()
+	if expire > 0 {
+		invite.ExpireTime = invite.CreatedTime.Add(expire)
+	}
+	err = u.inviteRepo.InsertInvite(ctx, invite)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupInviteFail,
+			Message: constant.MsgGroupInviteFail,
+		}
+	}
+	return nil
+}
+
+func (u *groupUsecase) GroupInvites(operatorId, groupId int64) ([]*model.GroupInvite, error) {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_MANAGE_INVITE)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := u.inviteRepo.FindGroupInvites(ctx, groupId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return invites, nil
+}
+
+func (u *groupUsecase) GroupRevokeInvite(operatorId int64, code string) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	invite, err := u.inviteRepo.FindInvite(ctx, code)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupInviteInvalid,
+			Message: constant.MsgGroupInviteInvalid,
+		}
+	}
+	err = u.auth.AuthorizeGroup(ctx, operatorId, invite.GroupId, ACTION_MANAGE_INVITE)
+	if err != nil {
+		return err
+	}
+	err = u.inviteRepo.DeleteInvite(ctx, invite.GroupId, code)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 凭邀请码加群，不需要群密码，容量检查与普通加群一致
+func (u *groupUsecase) GroupJoinByCode(groupToUser *model.GroupToUser, code string) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	invite, err := u.inviteRepo.FindInvite(ctx, code)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupInviteInvalid,
+			Message: constant.MsgGroupInviteInvalid,
+		}
+	}
+	userRoleId, err := u.repo.FindUserRoleId(ctx, invite.Role)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupJoinFail,
+			Message: constant.MsgGroupJoinFail,
+		}
+	}
+	err = u.inviteRepo.UpdateInviteUse(ctx, code)
+	if err != nil {
+		return err
+	}
+	groupToUser.GroupId = invite.GroupId
+	groupToUser.UserRoleId = userRoleId
+	err = u.repo.UpsertUserInGroup(ctx, groupToUser)
+	if err != nil {
+		u.inviteRepo.UpdateInviteRelease(ctx, code)
+		if joinRejected(err) {
+			return err
+		}
+		return &model.DError{
+			Code:    constant.ErrGroupJoinFail,
+			Message: constant.MsgGroupJoinFail,
+		}
+	}
+	return nil
+}
+
+// 设置入群方式，与修改群资料使用相同的权限
+func (u *groupUsecase) GroupSetJoinPolicy(operatorId, groupId int64, joinPolicy string) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	switch joinPolicy {
+	case JOIN_POLICY_OPEN, JOIN_POLICY_PASSWORD, JOIN_POLICY_APPROVAL, JOIN_POLICY_INVITE:
+	default:
+		return &model.DError{
+			Code:    constant.ErrGroupJoinPolicy,
+			Message: constant.MsgGroupJoinPolicy,
+		}
+	}
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_UPDATE_GROUP)
+	if err != nil {
+		return err
+	}
+	err = u.repo.UpdateGroupJoinPolicy(ctx, groupId, joinPolicy)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupUpdateFail,
+			Message: constant.MsgGroupUpdateFail,
+		}
+	}
+	return nil
+}
+
+// 列出群内待处理的入群申请
+func (u *groupUsecase) GroupRequests(operatorId, groupId int64, page *model.Page[*model.JoinRequest]) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_HANDLE_REQUEST)
+	if err != nil {
+		return err
+	}
+	err = u.requestRepo.FindJoinRequests(ctx, groupId, REQUEST_STATUS_PENDING, page)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 处理入群申请，先抢占申请状态避免多个管理员重复处理，同意后入群失败时恢复为待处理
+func (u *groupUsecase) GroupHandleRequest(operatorId, requestId int64, approve bool) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	joinRequest := &model.JoinRequest{
+		RequestId: requestId,
+	}
+	err := u.requestRepo.FindJoinRequest(ctx, joinRequest)
+	if err != nil || joinRequest.Status != REQUEST_STATUS_PENDING {
+		return &model.DError{
+			Code:    constant.ErrGroupRequestDone,
+			Message: constant.MsgGroupRequestDone,
+		}
+	}
+	err = u.auth.AuthorizeGroup(ctx, operatorId, joinRequest.GroupId, ACTION_HANDLE_REQUEST)
+	if err != nil {
+		return err
+	}
+	status := REQUEST_STATUS_REJECTED
+	if approve {
+		status = REQUEST_STATUS_APPROVED
+	}
+	err = u.requestRepo.UpdateJoinRequest(ctx, requestId, REQUEST_STATUS_PENDING, status, operatorId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupRequestDone,
+			Message: constant.MsgGroupRequestDone,
+		}
+	}
+	if approve {
+		err = u.approveRequest(ctx, joinRequest)
+		if err != nil {
+			u.requestRepo.UpdateJoinRequest(ctx, requestId, status, REQUEST_STATUS_PENDING, 0)
+			return err
+		}
+	}
+	joinRequest.Status = status
+	joinRequest.HandlerId = operatorId
+	// 申请人在线时推送处理结果
+	u.hub.Push(joinRequest.UserId, &ws.Event{
+		Type: ws.EVENT_JOIN,
+		Data: joinRequest,
+	})
+	return nil
+}
+
+func (u *groupUsecase) approveRequest(ctx context.Context, joinRequest *model.JoinRequest) error {
+	userRoleId, err := u.repo.FindUserRoleId(ctx, ROLE_JOINER)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupJoinFail,
+			Message: constant.MsgGroupJoinFail,
+		}
+	}
+	groupToUser := &model.GroupToUser{
+		GroupId:      joinRequest.GroupId,
+		UserId:       joinRequest.UserId,
+		UserNickname: joinRequest.UserNickname,
+		UserRoleId:   userRoleId,
+		UserDisturb:  1,
+	}
+	err = u.repo.UpsertUserInGroup(ctx, groupToUser)
+	if joinRejected(err) {
+		return err
+	}
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupJoinFail,
+			Message: constant.MsgGroupJoinFail,
+		}
+	}
+	return nil
+}
+
+// 封禁成员，成员仍在群内时一并移出，ttl 为 0 表示永久封禁
+func (u *groupUsecase) GroupBan(operatorId int64, groupBan *model.GroupBan, ttl time.Duration) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	if operatorId == groupBan.UserId {
+		return permissionDenied()
+	}
+	target := &model.GroupToUser{
+		GroupId: groupBan.GroupId,
+		UserId:  groupBan.UserId,
+	}
+	member := u.repo.FindGroupToUser(ctx, target) == nil
+	// 已不在群内的用户按普通成员处理
+	action := ACTION_KICK_JOINER
+	if member {
+		var ok bool
+		action, ok = kickAction(target.UserRole)
+		if !ok {
+			return permissionDenied()
+		}
+	}
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupBan.GroupId, action)
+	if err != nil {
+		return err
+	}
+	groupBan.OperatorId = operatorId
+	groupBan.CreatedTime = time.Now()
+	if ttl > 0 {
+		groupBan.ExpireTime = groupBan.CreatedTime.Add(ttl)
+	}
+	// 封禁与移出群在同一事务中完成
+	err = u.repo.UpsertGroupBan(ctx, groupBan)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+func (u *groupUsecase) GroupUnban(operatorId, groupId, userId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_KICK_JOINER)
+	if err != nil {
+		return err
+	}
+	err = u.repo.DeleteGroupBan(ctx, groupId, userId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+func (u *groupUsecase) GroupBans(operatorId, groupId int64, page *model.Page[*model.GroupBan]) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.auth.AuthorizeGroup(ctx, operatorId, groupId, ACTION_KICK_JOINER)
+	if err != nil {
+		return err
+	}
+	err = u.repo.FindGroupBans(ctx, groupId, page)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 禁言成员，duration 为 0 时解除禁言
+func (u *groupUsecase) GroupMute(operatorId, groupId, userId int64, duration time.Duration) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	if operatorId == userId {
+		return permissionDenied()
+	}
+	target := &model.GroupToUser{
+		GroupId: groupId,
+		UserId:  userId,
+	}
+	err := u.repo.FindGroupToUser(ctx, target)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupUpdateUserFail,
+			Message: constant.MsgGroupUpdateUserFail,
+		}
+	}
+	action, ok := muteAction(target.UserRole)
+	if !ok {
+		return permissionDenied()
+	}
+	err = u.auth.AuthorizeGroup(ctx, operatorId, groupId, action)
+	if err != nil {
+		return err
+	}
+	var muteUntil time.Time
+	if duration > 0 {
+		muteUntil = time.Now().Add(duration)
+	}
+	err = u.repo.UpdateGroupUserMute(ctx, groupId, userId, muteUntil)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupUpdateUserFail,
+			Message: constant.MsgGroupUpdateUserFail,
+		}
+	}
+	return nil
+}
+
+// 加群被拒绝的原因直接返回给用户
+func joinRejected(err error) bool {
+	derr, ok := err.(*model.DError)
+	if !ok {
+		return false
+	}
+	switch derr.Code {
+	case constant.ErrGroupFull, constant.ErrGroupAlreadyMember, constant.ErrGroupBanned:
+		return true
+	}
+	return false
+}
+
+func (u *groupUsecase) GroupAnnounce(operatorId int64, announcement *model.GroupAnnouncement) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.auth.AuthorizeGroup(ctx, operatorId, announcement.GroupId, ACTION_MANAGE_ANNOUNCEMENT)
+	if err != nil {
+		return err
+	}
+	announcement.CreatorId = operatorId
+	err = u.announceRepo.InsertAnnouncement(ctx, announcement)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	err = u.announceRepo.FindAnnouncement(ctx, announcement)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	return nil
+}
+
+// 修改公告内容或置顶、确认设置，公告所在的群以数据库记录为准
+func (u *groupUsecase) GroupUpdateAnnouncement(operatorId int64, announcement *model.GroupAnnouncement) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	current := &model.GroupAnnouncement{
+		AnnouncementId: announcement.AnnouncementId,
+	}
+	err := u.announceRepo.FindAnnouncement(ctx, current)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	err = u.auth.AuthorizeGroup(ctx, operatorId, current.GroupId, ACTION_MANAGE_ANNOUNCEMENT)
+	if err != nil {
+		return err
+	}
+	announcement.GroupId = current.GroupId
+	err = u.announceRepo.UpdateAnnouncement(ctx, announcement)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	err = u.announceRepo.FindAnnouncement(ctx, announcement)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	return nil
+}
+
+func (u *groupUsecase) GroupDeleteAnnouncement(operatorId, announcementId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	announcement := &model.GroupAnnouncement{
+		AnnouncementId: announcementId,
+	}
+	err := u.announceRepo.FindAnnouncement(ctx, announcement)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	err = u.auth.AuthorizeGroup(ctx, operatorId, announcement.GroupId, ACTION_MANAGE_ANNOUNCEMENT)
+	if err != nil {
+		return err
+	}
+	err = u.announceRepo.DeleteAnnouncement(ctx, announcementId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	return nil
+}
+
+// 群成员查看群公告，包含自己的确认状态
+func (u *groupUsecase) GroupAnnouncements(operatorId, groupId int64, page *model.Page[*model.GroupAnnouncement]) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	member := &model.GroupToUser{
+		GroupId: groupId,
+		UserId:  operatorId,
+	}
+	err := u.repo.FindGroupToUser(ctx, member)
+	if err != nil {
+		return permissionDenied()
+	}
+	err = u.announceRepo.FindAnnouncements(ctx, groupId, operatorId, page)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	return nil
+}
+
+func (u *groupUsecase) GroupAckAnnouncement(operatorId, announcementId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	announcement := &model.GroupAnnouncement{
+		AnnouncementId: announcementId,
+	}
+	err := u.announceRepo.FindAnnouncement(ctx, announcement)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	member := &model.GroupToUser{
+		GroupId: announcement.GroupId,
+		UserId:  operatorId,
+	}
+	err = u.repo.FindGroupToUser(ctx, member)
+	if err != nil {
+		return permissionDenied()
+	}
+	err = u.announceRepo.InsertAnnouncementAck(ctx, announcementId, operatorId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrGroupAnnounceFail,
+			Message: constant.MsgGroupAnnounceFail,
+		}
+	}
+	return nil
+}
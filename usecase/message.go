@@ -0,0 +1,665 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/internal/storage"
+	"github.com/wendisx/gorchat/internal/validator"
+	"github.com/wendisx/gorchat/internal/ws"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/repository"
+)
+
+// 对话类型，对应 im_dialog
+const (
+	DIALOG_SINGLE = 1
+	DIALOG_GROUP  = 2
+)
+
+// 消息类型，对应 im_message_type
+const (
+	MESSAGE_TYPE_TEXT  = "text"
+	MESSAGE_TYPE_IMAGE = "image"
+	MESSAGE_TYPE_AUDIO = "audio"
+	MESSAGE_TYPE_VIDEO = "video"
+	MESSAGE_TYPE_LINK  = "link"
+)
+
+// 群当前人数超过该值时改用读扩散，避免大群每条消息写入全部成员时间线
+const (
+	GROUP_WRITE_DIFFUSION_LIMIT = 200
+)
+
+// 单次同步最多拉取的时间线数量
+const (
+	SYNC_MAX_TIMELINES = 50
+)
+
+// 消息状态，对应 im_message_status
+const (
+	MESSAGE_STATUS_UNREAD    = "Unread"
+	MESSAGE_STATUS_READ      = "Read"
+	MESSAGE_STATUS_WITHDRAWN = "WithDrawn"
+	MESSAGE_STATUS_RESENT    = "Resent"
+	MESSAGE_STATUS_DISCARDED = "Discarded"
+)
+
+// 消息状态机: 当前状态 -> 允许迁移到的状态
+var messageTransitions = map[string][]string{
+	MESSAGE_STATUS_UNREAD:    {MESSAGE_STATUS_READ, MESSAGE_STATUS_WITHDRAWN, MESSAGE_STATUS_RESENT, MESSAGE_STATUS_DISCARDED},
+	MESSAGE_STATUS_RESENT:    {MESSAGE_STATUS_UNREAD, MESSAGE_STATUS_READ, MESSAGE_STATUS_WITHDRAWN, MESSAGE_STATUS_DISCARDED},
+	MESSAGE_STATUS_READ:      {MESSAGE_STATUS_WITHDRAWN, MESSAGE_STATUS_DISCARDED},
+	MESSAGE_STATUS_WITHDRAWN: {MESSAGE_STATUS_DISCARDED},
+	MESSAGE_STATUS_DISCARDED: {},
+}
+
+// 判断状态迁移是否合法
+func canTransit(from, to string) bool {
+	for _, next := range messageTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// 所有可以合法迁移到 to 的状态，用于批量更新时限定 where 条件
+func transitSources(to string) []string {
+	var sources []string
+	for from := range messageTransitions {
+		if canTransit(from, to) {
+			sources = append(sources, from)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+type MessageUsecase interface {
+	GetLogger() log.Logger
+	SendSingle(message *model.Message) (int64, error)
+	SendGroup(message *model.Message) (*model.Timeline, error)
+	Sync(userId int64, cursors []*model.SyncCursor, pageSize int) ([]*model.TimelinePage, error)
+	MarkRead(readReceipt *model.ReadReceipt) error
+	Recall(recallNotice *model.RecallNotice) error
+}
+
+type messageUsecase struct {
+	repo      repository.MessageRepository
+	groupRepo repository.GroupRepository
+	blockRepo repository.BlockRepository
+	convRepo  repository.ConversationRepository
+	hub       ws.Hub
+	recall    time.Duration // 发送者可撤回消息的时限
+	va        *validator.Validator
+	store     storage.BlobStore
+	logger    log.Logger
+	c         context.Context
+	t         time.Duration
+}
+
+func NewMessageUsecase(repo repository.MessageRepository, groupRepo repository.GroupRepository, blockRepo repository.BlockRepository, convRepo repository.ConversationRepository, hub ws.Hub, recall time.Duration, va *validator.Validator, store storage.BlobStore) MessageUsecase {
+	return &messageUsecase{
+		repo:      repo,
+		groupRepo: groupRepo,
+		blockRepo: blockRepo,
+		convRepo:  convRepo,
+		hub:       hub,
+		recall:    recall,
+		va:        va,
+		store:     store,
+		logger:    repo.GetLogger(),
+		c:         context.Background(),
+		t:         5 * time.Second,
+	}
+}
+
+func (u *messageUsecase) GetLogger() log.Logger {
+	return u.logger
+}
+
+// 发送单聊消息，返回发送者时间线上的序列号
+func (u *messageUsecase) SendSingle(message *model.Message) (int64, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	if message.Sender == message.Receiver {
+		return -1, &model.DError{
+			Code:    constant.ErrMessageSendFail,
+			Message: constant.MsgMessageSendFail,
+		}
+	}
+	err := u.checkContent(ctx, message)
+	if err != nil {
+		return -1, err
+	}
+	_, err = u.repo.FindSingleId(ctx, message.Sender, message.Receiver)
+	if err != nil {
+		return -1, &model.DError{
+			Code:    constant.ErrMessageNotContact,
+			Message: constant.MsgMessageNotContact,
+		}
+	}
+	// 接收者屏蔽了发送者时拒绝投递
+	blocked, err := u.blockRepo.FindBlocked(ctx, message.Receiver, message.Sender)
+	if err != nil {
+		return -1, &model.DError{
+			Code:    constant.ErrMessageSendFail,
+			Message: constant.MsgMessageSendFail,
+		}
+	}
+	if blocked {
+		return -1, &model.DError{
+			Code:    constant.ErrUserBlocked,
+			Message: constant.MsgUserBlocked,
+		}
+	}
+	message.DialogType = DIALOG_SINGLE
+	message.Status = MESSAGE_STATUS_UNREAD
+	message.SendTime = time.Now()
+	// 写扩散: 发送者与接收者各自的时间线都写入一条记录
+	senderTimeline := &model.Timeline{
+		TimelineType: repository.TIMELINE_USER,
+		TimelineId:   message.Sender,
+		Sender:       message.Sender,
+		DialogType:   DIALOG_SINGLE,
+	}
+	receiverTimeline := &model.Timeline{
+		TimelineType: repository.TIMELINE_USER,
+		TimelineId:   message.Receiver,
+		Sender:       message.Sender,
+		DialogType:   DIALOG_SINGLE,
+	}
+	timelines := []*model.Timeline{senderTimeline, receiverTimeline}
+	err = u.repo.InsertMessage(ctx, message, timelines)
+	if err != nil {
+		return -1, &model.DError{
+			Code:    constant.ErrMessageSendFail,
+			Message: constant.MsgMessageSendFail,
+		}
+	}
+	// 未读数只用于会话列表展示，redis 写入失败不影响消息投递
+	u.convRepo.IncrUnread(ctx, []int64{message.Receiver}, DIALOG_SINGLE, message.Sender)
+	u.push(message, receiverTimeline)
+	u.push(message, senderTimeline)
+	return senderTimeline.SequenceId, nil
+}
+
+// 发送群消息，消息本体只存储一份，返回发送者可见的时间线位置
+func (u *messageUsecase) SendGroup(message *model.Message) (*model.Timeline, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	err := u.checkContent(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	groupToUser := &model.GroupToUser{
+		GroupId: message.Receiver,
+		UserId:  message.Sender,
+	}
+	err = u.groupRepo.FindGroupToUser(ctx, groupToUser)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrMessageNotMember,
+			Message: constant.MsgMessageNotMember,
+		}
+	}
+	if groupToUser.MuteUntil.After(time.Now()) {
+		return nil, &model.DError{
+			Code:    constant.ErrGroupMuted,
+			Message: constant.MsgGroupMuted,
+		}
+	}
+	memberIds, err := u.groupRepo.FindGroupMemberIds(ctx, message.Receiver)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrMessageSendFail,
+			Message: constant.MsgMessageSendFail,
+		}
+	}
+	message.DialogType = DIALOG_GROUP
+	message.Status = MESSAGE_STATUS_UNREAD
+	message.SendTime = time.Now()
+	var timelines []*model.Timeline
+	var senderTimeline *model.Timeline
+	readDiffusion := groupToUser.GroupCurrentSize > GROUP_WRITE_DIFFUSION_LIMIT
+	if readDiffusion {
+		// 读扩散: 只写群时间线，成员从群时间线拉取
+		senderTimeline = &model.Timeline{
+			TimelineType: repository.TIMELINE_GROUP,
+			TimelineId:   message.Receiver,
+			Sender:       message.Sender,
+			DialogType:   DIALOG_GROUP,
+		}
+		timelines = append(timelines, senderTimeline)
+	} else {
+		// 写扩散: 每个成员的时间线各写一条，收件箱读取更快
+		for _, memberId := range memberIds {
+			timeline := &model.Timeline{
+				TimelineType: repository.TIMELINE_USER,
+				TimelineId:   memberId,
+				Sender:       message.Sender,
+				DialogType:   DIALOG_GROUP,
+			}
+			if memberId == message.Sender {
+				senderTimeline = timeline
+			}
+			timelines = append(timelines, timeline)
+		}
+		if senderTimeline == nil {
+			return nil, &model.DError{
+				Code:    constant.ErrMessageNotMember,
+				Message: constant.MsgMessageNotMember,
+			}
+		}
+	}
+	err = u.repo.InsertMessage(ctx, message, timelines)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrMessageSendFail,
+			Message: constant.MsgMessageSendFail,
+		}
+	}
+	receivers := make([]int64, 0, len(memberIds))
+	for _, memberId := range memberIds {
+		if memberId != message.Sender {
+			receivers = append(receivers, memberId)
+		}
+	}
+	// 未读数只用于会话列表展示，redis 写入失败时已由仓储记录日志，不影响消息投递
+	u.convRepo.IncrUnread(ctx, receivers, DIALOG_GROUP, message.Receiver)
+	if readDiffusion {
+		for _, memberId := range memberIds {
+			u.pushTo(memberId, message, senderTimeline)
+		}
+	} else {
+		for _, timeline := range timelines {
+			u.push(message, timeline)
+		}
+	}
+	return senderTimeline, nil
+}
+
+// 增量同步: 用户收件箱以及所在群中存在的群时间线，未携带游标时从头拉取
+func (u *messageUsecase) Sync(userId int64, cursors []*model.SyncCursor, pageSize int) ([]*model.TimelinePage, error) {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	groups, err := u.groupRepo.FindUserGroups(ctx, userId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrMessageSyncFail,
+			Message: constant.MsgMessageSyncFail,
+		}
+	}
+	// 用户可以读取的时间线: 自己的收件箱以及所在群的群时间线
+	allowed := map[timelineKey]struct{}{{repository.TIMELINE_USER, userId}: {}}
+	for _, group := range groups {
+		allowed[timelineKey{repository.TIMELINE_GROUP, group.GroupId}] = struct{}{}
+	}
+	if len(cursors) == 0 {
+		cursors = append(cursors, &model.SyncCursor{
+			TimelineType: repository.TIMELINE_USER,
+			TimelineId:   userId,
+		})
+		// 群人数回落到写扩散阈值以下后不再写群时间线，但之前读扩散的历史仍在群时间线上
+		groupIds := make([]int64, 0, len(groups))
+		for _, group := range groups {
+			groupIds = append(groupIds, group.GroupId)
+		}
+		timelineIds, err := u.repo.FindGroupTimelines(ctx, groupIds)
+		if err != nil {
+			return nil, &model.DError{
+				Code:    constant.ErrMessageSyncFail,
+				Message: constant.MsgMessageSyncFail,
+			}
+		}
+		for _, timelineId := range timelineIds {
+			cursors = append(cursors, &model.SyncCursor{
+				TimelineType: repository.TIMELINE_GROUP,
+				TimelineId:   timelineId,
+			})
+		}
+	}
+	if len(cursors) > SYNC_MAX_TIMELINES {
+		return nil, &model.DError{
+			Code:    constant.ErrMessageSyncFail,
+			Message: constant.MsgMessageSyncFail,
+		}
+	}
+	var timelinePages []*model.TimelinePage
+	for _, cursor := range cursors {
+		if _, ok := allowed[timelineKey{cursor.TimelineType, cursor.TimelineId}]; !ok {
+			return nil, &model.DError{
+				Code:    constant.ErrMessageNotMember,
+				Message: constant.MsgMessageNotMember,
+			}
+		}
+		// 多取一条用于判断是否还有下一页
+		items, err := u.repo.FindTimelineMessages(ctx, userId, cursor, pageSize+1)
+		if err != nil {
+			return nil, &model.DError{
+				Code:    constant.ErrMessageSyncFail,
+				Message: constant.MsgMessageSyncFail,
+			}
+		}
+		hasMore := len(items) > pageSize
+		if hasMore {
+			items = items[:pageSize]
+		}
+		for _, item := range items {
+			decodeContent(item)
+		}
+		next := cursor.SequenceId
+		if len(items) > 0 {
+			next = items[len(items)-1].SequenceId
+		}
+		timelinePages = append(timelinePages, &model.TimelinePage{
+			TimelineType: cursor.TimelineType,
+			TimelineId:   cursor.TimelineId,
+			Page:         model.NewCursorPage(next, pageSize, hasMore, items),
+		})
+	}
+	return timelinePages, nil
+}
+
+// 标记对话中序列号之前(含)的消息已读
+// 单聊将对方发来的消息迁移为已读并回执给发送者，群聊推进成员的已读位置并累加消息已读人数
+func (u *messageUsecase) MarkRead(readReceipt *model.ReadReceipt) error {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	readReceipt.ReadTime = time.Now()
+	switch readReceipt.DialogType {
+	case DIALOG_SINGLE:
+		// 单聊消息只存在于双方收件箱
+		readReceipt.TimelineType = repository.TIMELINE_USER
+		readReceipt.TimelineId = readReceipt.ReaderId
+		messageIds, err := u.repo.UpdateSingleRead(ctx, readReceipt, transitSources(MESSAGE_STATUS_READ), MESSAGE_STATUS_READ)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageReadFail,
+				Message: constant.MsgMessageReadFail,
+			}
+		}
+		readReceipt.MessageIds = messageIds
+		if len(messageIds) > 0 {
+			u.hub.Push(readReceipt.PeerId, &ws.Event{
+				Type: ws.EVENT_READ,
+				Data: readReceipt,
+			})
+		}
+	case DIALOG_GROUP:
+		groupToUser := &model.GroupToUser{
+			GroupId: readReceipt.PeerId,
+			UserId:  readReceipt.ReaderId,
+		}
+		err := u.groupRepo.FindGroupToUser(ctx, groupToUser)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageNotMember,
+				Message: constant.MsgMessageNotMember,
+			}
+		}
+		// 写扩散的群消息在成员收件箱，读扩散的在群时间线
+		inbox := readReceipt.TimelineType == repository.TIMELINE_USER && readReceipt.TimelineId == readReceipt.ReaderId
+		groupTimeline := readReceipt.TimelineType == repository.TIMELINE_GROUP && readReceipt.TimelineId == readReceipt.PeerId
+		if !inbox && !groupTimeline {
+			return &model.DError{
+				Code:    constant.ErrMessageReadFail,
+				Message: constant.MsgMessageReadFail,
+			}
+		}
+		messageIds, err := u.repo.UpdateGroupRead(ctx, readReceipt)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageReadFail,
+				Message: constant.MsgMessageReadFail,
+			}
+		}
+		readReceipt.MessageIds = messageIds
+	default:
+		return &model.DError{
+			Code:    constant.ErrMessageReadFail,
+			Message: constant.MsgMessageReadFail,
+		}
+	}
+	// 只扣除本次新读到的消息，回执较旧的消息不影响之后收到的未读数
+	// 未读数只用于会话列表展示，redis 写入失败不影响已读状态
+	u.convRepo.DecrUnread(ctx, readReceipt.ReaderId, readReceipt.DialogType, readReceipt.PeerId, int64(len(readReceipt.MessageIds)))
+	return nil
+}
+
+// 撤回消息: 发送者需在时限内撤回，群主和管理员撤回群消息不受时限限制
+// 撤回后消息内容被清空，对话中的在线用户收到撤回通知
+func (u *messageUsecase) Recall(recallNotice *model.RecallNotice) error {
+	ctx, cancel := context.WithTimeout(u.c, u.t)
+	defer cancel()
+	message, err := u.repo.FindMessage(ctx, recallNotice.MessageId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrMessageNotFound,
+			Message: constant.MsgMessageNotFound,
+		}
+	}
+	var receivers []int64
+	privileged := false
+	switch message.DialogType {
+	case DIALOG_SINGLE:
+		if recallNotice.OperatorId != message.Sender && recallNotice.OperatorId != message.Receiver {
+			return &model.DError{
+				Code:    constant.ErrMessageNotFound,
+				Message: constant.MsgMessageNotFound,
+			}
+		}
+		receivers = []int64{message.Sender, message.Receiver}
+	case DIALOG_GROUP:
+		groupToUser := &model.GroupToUser{
+			GroupId: message.Receiver,
+			UserId:  recallNotice.OperatorId,
+		}
+		err = u.groupRepo.FindGroupToUser(ctx, groupToUser)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageNotMember,
+				Message: constant.MsgMessageNotMember,
+			}
+		}
+		privileged = groupAllowed(groupToUser.UserRole, ACTION_RECALL_MESSAGE)
+		receivers, err = u.groupRepo.FindGroupMemberIds(ctx, message.Receiver)
+		if err != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageRecallFail,
+				Message: constant.MsgMessageRecallFail,
+			}
+		}
+	default:
+		return &model.DError{
+			Code:    constant.ErrMessageRecallFail,
+			Message: constant.MsgMessageRecallFail,
+		}
+	}
+	if !privileged {
+		if recallNotice.OperatorId != message.Sender {
+			return &model.DError{
+				Code:    constant.ErrMessageRecallFail,
+				Message: constant.MsgMessageRecallFail,
+			}
+		}
+		if time.Since(message.SendTime) > u.recall {
+			return &model.DError{
+				Code:    constant.ErrMessageRecallExp,
+				Message: constant.MsgMessageRecallExp,
+			}
+		}
+	}
+	if !canTransit(message.Status, MESSAGE_STATUS_WITHDRAWN) {
+		return &model.DError{
+			Code:    constant.ErrMessageRecallFail,
+			Message: constant.MsgMessageRecallFail,
+		}
+	}
+	err = u.repo.UpdateRecall(ctx, message.MessageId, transitSources(MESSAGE_STATUS_WITHDRAWN), MESSAGE_STATUS_WITHDRAWN)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrMessageRecallFail,
+			Message: constant.MsgMessageRecallFail,
+		}
+	}
+	recallNotice.Sender = message.Sender
+	recallNotice.Receiver = message.Receiver
+	recallNotice.DialogType = message.DialogType
+	recallNotice.RecallTime = time.Now()
+	event := &ws.Event{
+		Type: ws.EVENT_RECALL,
+		Data: recallNotice,
+	}
+	for _, receiver := range receivers {
+		u.hub.Push(receiver, event)
+	}
+	return nil
+}
+
+// 按消息类型校验内容: 文本消息只允许携带文本，其余类型校验负载后以规范化的 json 存储
+func (u *messageUsecase) checkContent(ctx context.Context, message *model.Message) error {
+	var payload any
+	switch message.Type {
+	case MESSAGE_TYPE_TEXT:
+		if message.Text == "" || len(message.Content) > 0 {
+			return &model.DError{
+				Code:    constant.ErrMessageContent,
+				Message: constant.MsgMessageContent,
+			}
+		}
+		return nil
+	case MESSAGE_TYPE_IMAGE, MESSAGE_TYPE_AUDIO, MESSAGE_TYPE_VIDEO:
+		var media model.MediaPayload
+		if json.Unmarshal(message.Content, &media) != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageContent,
+				Message: constant.MsgMessageContent,
+			}
+		}
+		err := u.checkMediaBlob(ctx, &media)
+		if err != nil {
+			return err
+		}
+		if !checkMedia(message.Type, &media) {
+			return &model.DError{
+				Code:    constant.ErrMessageContent,
+				Message: constant.MsgMessageContent,
+			}
+		}
+		message.BlobId = media.BlobId
+		payload = &media
+	case MESSAGE_TYPE_LINK:
+		var link model.LinkPayload
+		if json.Unmarshal(message.Content, &link) != nil {
+			return &model.DError{
+				Code:    constant.ErrMessageContent,
+				Message: constant.MsgMessageContent,
+			}
+		}
+		payload = &link
+	default:
+		return &model.DError{
+			Code:    constant.ErrMessageContent,
+			Message: constant.MsgMessageContent,
+		}
+	}
+	if u.va.Check(payload) != nil {
+		return &model.DError{
+			Code:    constant.ErrMessageContent,
+			Message: constant.MsgMessageContent,
+		}
+	}
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrMessageContent,
+			Message: constant.MsgMessageContent,
+		}
+	}
+	message.Text = ""
+	message.Content = content
+	return nil
+}
+
+// 以存储中的附件为准: 声明的大小必须与附件一致，mime 类型取附件内容的嗅探结果
+func (u *messageUsecase) checkMediaBlob(ctx context.Context, media *model.MediaPayload) error {
+	r, blob, err := u.store.Open(ctx, media.BlobId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrFileNotFound,
+			Message: constant.MsgFileNotFound,
+		}
+	}
+	r.Close()
+	mimeType, _, err := mime.ParseMediaType(blob.MimeType)
+	if err != nil || blob.Size != media.Size {
+		return &model.DError{
+			Code:    constant.ErrMessageContent,
+			Message: constant.MsgMessageContent,
+		}
+	}
+	media.MimeType = mimeType
+	return nil
+}
+
+// 媒体负载的 mime 主类型与消息类型一致，音视频需要时长，图片视频需要尺寸
+func checkMedia(messageType string, media *model.MediaPayload) bool {
+	if !strings.HasPrefix(media.MimeType, messageType+"/") {
+		return false
+	}
+	if messageType != MESSAGE_TYPE_IMAGE && media.Duration <= 0 {
+		return false
+	}
+	if messageType != MESSAGE_TYPE_AUDIO && (media.Width <= 0 || media.Height <= 0) {
+		return false
+	}
+	return true
+}
+
+// 非文本消息的内容为 json 负载，撤回后内容为空
+func decodeContent(item *model.MessageItem) {
+	if item.Type == MESSAGE_TYPE_TEXT || item.Text == "" {
+		return
+	}
+	item.Content = json.RawMessage(item.Text)
+	item.Text = ""
+}
+
+// 时间线由类型和 id 共同确定，用户收件箱与群时间线的 id 空间可能重叠
+type timelineKey struct {
+	timelineType int
+	timelineId   int64
+}
+
+// 推送给时间线所属的在线用户，不在线的由客户端重连后同步
+func (u *messageUsecase) push(message *model.Message, timeline *model.Timeline) {
+	u.pushTo(timeline.TimelineId, message, timeline)
+}
+
+func (u *messageUsecase) pushTo(userId int64, message *model.Message, timeline *model.Timeline) {
+	u.hub.Push(userId, &ws.Event{
+		Type: ws.EVENT_MESSAGE,
+		Data: &model.MessageItem{
+			TimelineType: timeline.TimelineType,
+			TimelineId:   timeline.TimelineId,
+			SequenceId:   timeline.SequenceId,
+			MessageId:    message.MessageId,
+			Sender:       message.Sender,
+			Receiver:     message.Receiver,
+			DialogType:   message.DialogType,
+			Type:         message.Type,
+			Text:         message.Text,
+			Content:      message.Content,
+			Status:       message.Status,
+			SendTime:     message.SendTime,
+		},
+	})
+}
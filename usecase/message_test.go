@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wendisx/gorchat/internal/storage"
+	"github.com/wendisx/gorchat/internal/validator"
+	"github.com/wendisx/gorchat/model"
+)
+
+func TestCanTransit(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		from string
+		to   string
+		ok   bool
+	}{
+		{MESSAGE_STATUS_UNREAD, MESSAGE_STATUS_READ, true},
+		{MESSAGE_STATUS_RESENT, MESSAGE_STATUS_READ, true},
+		{MESSAGE_STATUS_READ, MESSAGE_STATUS_WITHDRAWN, true},
+		{MESSAGE_STATUS_READ, MESSAGE_STATUS_UNREAD, false},
+		{MESSAGE_STATUS_WITHDRAWN, MESSAGE_STATUS_READ, false},
+		{MESSAGE_STATUS_DISCARDED, MESSAGE_STATUS_UNREAD, false},
+	}
+	for _, c := range cases {
+		if canTransit(c.from, c.to) != c.ok {
+			t.Errorf("-- %s -> %s expect %v", c.from, c.to, c.ok)
+		}
+	}
+}
+
+func TestTransitSources(t *testing.T) {
+	t.Parallel()
+	sources := transitSources(MESSAGE_STATUS_READ)
+	expect := []string{MESSAGE_STATUS_RESENT, MESSAGE_STATUS_UNREAD}
+	if !reflect.DeepEqual(sources, expect) {
+		t.Errorf("-- sources %v expect %v", sources, expect)
+	}
+}
+
+func TestCheckContent(t *testing.T) {
+	t.Parallel()
+	store := storage.NewLocalStore(t.TempDir(), 1024)
+	put := func(content string) *storage.Blob {
+		blob, err := store.Put(context.Background(), strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("-- put blob: %v", err)
+		}
+		return blob
+	}
+	text := put("content")
+	image := put("\x89PNG\r\n\x1a\n\x00\x00")
+	audio := put("ID3\x03\x00\x00\x00\x00\x00\x00")
+	media := func(blob *storage.Blob, size int64, rest string) []byte {
+		return []byte(`{"blobId":"` + blob.Id + `","size":` + strconv.FormatInt(size, 10) + `,` + rest + `}`)
+	}
+	u := &messageUsecase{va: validator.NewValidator(), store: store}
+	missing := strings.Repeat("0", 64)
+	cases := []struct {
+		message *model.Message
+		ok      bool
+	}{
+		{&model.Message{Type: MESSAGE_TYPE_TEXT, Text: "hello"}, true},
+		{&model.Message{Type: MESSAGE_TYPE_TEXT}, false},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: media(image, image.Size, `"mimeType":"image/png","width":1,"height":1`)}, true},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: media(image, image.Size, `"mimeType":"video/mp4","width":1,"height":1`)}, true},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: media(image, image.Size+1, `"mimeType":"image/png","width":1,"height":1`)}, false},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: media(text, 10, `"mimeType":"image/png","width":1,"height":1`)}, false},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: media(text, text.Size, `"mimeType":"image/png","width":1,"height":1`)}, false},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: []byte(`{"blobId":"` + missing + `","size":10,"mimeType":"image/png","width":1,"height":1}`)}, false},
+		{&model.Message{Type: MESSAGE_TYPE_IMAGE, Content: []byte(`{"blobId":"abc","size":10,"mimeType":"image/png","width":1,"height":1}`)}, false},
+		{&model.Message{Type: MESSAGE_TYPE_AUDIO, Content: media(audio, audio.Size, `"mimeType":"audio/mpeg"`)}, false},
+		{&model.Message{Type: MESSAGE_TYPE_AUDIO, Content: media(audio, audio.Size, `"mimeType":"audio/mpeg","duration":3`)}, true},
+		{&model.Message{Type: MESSAGE_TYPE_LINK, Content: []byte(`{"url":"https://example.com","title":"example"}`)}, true},
+		{&model.Message{Type: MESSAGE_TYPE_LINK, Content: []byte(`{"url":"example.com"}`)}, false},
+		{&model.Message{Type: "file", Content: []byte(`{}`)}, false},
+	}
+	for _, c := range cases {
+		err := u.checkContent(context.Background(), c.message)
+		if (err == nil) != c.ok {
+			t.Errorf("-- %s %s expect %v got %v", c.message.Type, c.message.Content, c.ok, err)
+		}
+	}
+}
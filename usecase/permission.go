@@ -0,0 +1,101 @@
+package usecase
+
+import "sort"
+
+// 群操作
+const (
+	ACTION_UPDATE_GROUP        = "updateGroup"        // 修改群资料
+	ACTION_DELETE_GROUP        = "deleteGroup"        // 解散群聊
+	ACTION_TRANSFER_OWNER      = "transferOwner"      // 转让群主
+	ACTION_KICK_JOINER         = "kickJoiner"         // 移除普通成员
+	ACTION_KICK_ADMIN          = "kickAdmin"          // 移除管理员
+	ACTION_PROMOTE_ADMIN       = "promoteAdmin"       // 设置管理员
+	ACTION_DEMOTE_ADMIN        = "demoteAdmin"        // 取消管理员
+	ACTION_SET_MEMBER_NICKNAME = "setMemberNickname"  // 修改他人群昵称
+	ACTION_MANAGE_INVITE       = "manageInvite"       // 管理邀请码
+	ACTION_HANDLE_REQUEST      = "handleRequest"      // 处理入群申请
+	ACTION_MUTE_JOINER         = "muteJoiner"         // 禁言普通成员
+	ACTION_MUTE_ADMIN          = "muteAdmin"          // 禁言管理员
+	ACTION_MANAGE_ANNOUNCEMENT = "manageAnnouncement" // 管理群公告
+	ACTION_RECALL_MESSAGE      = "recallMessage"      // 不限时撤回任意成员消息
+)
+
+// 群权限矩阵: 操作 -> 允许的角色
+var groupPermissions = map[string][]string{
+	ACTION_UPDATE_GROUP:        {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_DELETE_GROUP:        {ROLE_OWNER},
+	ACTION_TRANSFER_OWNER:      {ROLE_OWNER},
+	ACTION_KICK_JOINER:         {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_KICK_ADMIN:          {ROLE_OWNER},
+	ACTION_PROMOTE_ADMIN:       {ROLE_OWNER},
+	ACTION_DEMOTE_ADMIN:        {ROLE_OWNER},
+	ACTION_SET_MEMBER_NICKNAME: {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_MANAGE_INVITE:       {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_HANDLE_REQUEST:      {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_MUTE_JOINER:         {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_MUTE_ADMIN:          {ROLE_OWNER},
+	ACTION_MANAGE_ANNOUNCEMENT: {ROLE_OWNER, ROLE_ADMIN},
+	ACTION_RECALL_MESSAGE:      {ROLE_OWNER, ROLE_ADMIN},
+}
+
+func GroupPermissions() map[string][]string {
+	permissions := make(map[string][]string, len(groupPermissions))
+	for action, roles := range groupPermissions {
+		permissions[action] = append([]string(nil), roles...)
+	}
+	return permissions
+}
+
+func groupAllowed(role, action string) bool {
+	for _, r := range groupPermissions[action] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// 角色可执行的全部操作
+func groupActions(role string) []string {
+	actions := make([]string, 0, len(groupPermissions))
+	for action := range groupPermissions {
+		if groupAllowed(role, action) {
+			actions = append(actions, action)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// 移除或封禁成员对应的操作，群主不能被移除
+func kickAction(targetRole string) (string, bool) {
+	switch targetRole {
+	case ROLE_JOINER:
+		return ACTION_KICK_JOINER, true
+	case ROLE_ADMIN:
+		return ACTION_KICK_ADMIN, true
+	}
+	return "", false
+}
+
+// 禁言成员对应的操作，群主不能被禁言
+func muteAction(targetRole string) (string, bool) {
+	switch targetRole {
+	case ROLE_JOINER:
+		return ACTION_MUTE_JOINER, true
+	case ROLE_ADMIN:
+		return ACTION_MUTE_ADMIN, true
+	}
+	return "", false
+}
+
+// 角色变更对应的操作，只支持普通成员与管理员之间互相转换
+func roleAction(targetRole, newRole string) (string, bool) {
+	switch {
+	case targetRole == ROLE_JOINER && newRole == ROLE_ADMIN:
+		return ACTION_PROMOTE_ADMIN, true
+	case targetRole == ROLE_ADMIN && newRole == ROLE_JOINER:
+		return ACTION_DEMOTE_ADMIN, true
+	}
+	return "", false
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKickPermission(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		role   string
+		target string
+		ok     bool
+	}{
+		{ROLE_OWNER, ROLE_ADMIN, true},
+		{ROLE_OWNER, ROLE_JOINER, true},
+		{ROLE_ADMIN, ROLE_JOINER, true},
+		{ROLE_ADMIN, ROLE_ADMIN, false},
+		{ROLE_ADMIN, ROLE_OWNER, false},
+		{ROLE_JOINER, ROLE_JOINER, false},
+	}
+	for _, c := range cases {
+		action, ok := kickAction(c.target)
+		if (ok && groupAllowed(c.role, action)) != c.ok {
+			t.Errorf("-- %s kick %s expect %v", c.role, c.target, c.ok)
+		}
+	}
+}
+
+func TestMutePermission(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		role   string
+		target string
+		ok     bool
+	}{
+		{ROLE_OWNER, ROLE_ADMIN, true},
+		{ROLE_OWNER, ROLE_JOINER, true},
+		{ROLE_ADMIN, ROLE_JOINER, true},
+		{ROLE_ADMIN, ROLE_ADMIN, false},
+		{ROLE_ADMIN, ROLE_OWNER, false},
+		{ROLE_JOINER, ROLE_JOINER, false},
+	}
+	for _, c := range cases {
+		action, ok := muteAction(c.target)
+		if (ok && groupAllowed(c.role, action)) != c.ok {
+			t.Errorf("-- %s mute %s expect %v", c.role, c.target, c.ok)
+		}
+	}
+}
+
+func TestRolePermission(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		role    string
+		target  string
+		newRole string
+		ok      bool
+	}{
+		{ROLE_OWNER, ROLE_JOINER, ROLE_ADMIN, true},
+		{ROLE_OWNER, ROLE_ADMIN, ROLE_JOINER, true},
+		{ROLE_OWNER, ROLE_JOINER, ROLE_OWNER, false},
+		{ROLE_ADMIN, ROLE_JOINER, ROLE_ADMIN, false},
+		{ROLE_ADMIN, ROLE_ADMIN, ROLE_JOINER, false},
+		{ROLE_JOINER, ROLE_JOINER, ROLE_ADMIN, false},
+	}
+	for _, c := range cases {
+		action, ok := roleAction(c.target, c.newRole)
+		if (ok && groupAllowed(c.role, action)) != c.ok {
+			t.Errorf("-- %s set %s to %s expect %v", c.role, c.target, c.newRole, c.ok)
+		}
+	}
+}
+
+func TestGroupActions(t *testing.T) {
+	t.Parallel()
+	if actions := groupActions(ROLE_JOINER); len(actions) != 0 {
+		t.Errorf("-- joiner actions %v expect none", actions)
+	}
+	expect := []string{ACTION_HANDLE_REQUEST, ACTION_KICK_JOINER, ACTION_MANAGE_ANNOUNCEMENT, ACTION_MANAGE_INVITE, ACTION_MUTE_JOINER, ACTION_RECALL_MESSAGE, ACTION_SET_MEMBER_NICKNAME, ACTION_UPDATE_GROUP}
+	if actions := groupActions(ROLE_ADMIN); !reflect.DeepEqual(actions, expect) {
+		t.Errorf("-- admin actions %v expect %v", actions, expect)
+	}
+}
@@ -12,6 +12,15 @@ import (
 	"github.com/wendisx/gorchat/repository"
 )
 
+// 单聊申请状态
+const (
+	SINGLE_STATUS_PENDING = iota
+	SINGLE_STATUS_ACCEPTED
+	SINGLE_STATUS_DECLINED
+	SINGLE_STATUS_CANCELLED
+	SINGLE_STATUS_EXPIRED
+)
+
 type SingleUsecase interface {
 	GetLogger() log.Logger
 	InviteSingle(singleInvite *model.SingleInvite) (*model.SingleInviter, error)
@@ -20,22 +29,31 @@ type SingleUsecase interface {
 	UpdateByInvitee(singleInvitee *model.SingleInvitee) error
 	GetDetailForInviter(singleInviter *model.SingleInviter) error
 	GetDetailForInvitee(singleInvitee *model.SingleInvitee) error
-	Delete(singleDelete *model.SingleDelete) error
+	Delete(operatorId int64, singleDelete *model.SingleDelete) error
+	Contacts(operatorId, tagId int64, page *model.Page[*model.Contact]) error
+	Requests(operatorId int64, incoming bool, page *model.Page[*model.SingleRequest]) error
+	Decline(operatorId, singleId int64) error
+	Cancel(operatorId, singleId int64) error
+	ExpireRequests(ctx context.Context, ttl time.Duration) (int64, error)
 }
 
 type singleUsecase struct {
-	repo   repository.SingleRepository
-	logger log.Logger
-	c      context.Context
-	t      time.Duration
+	repo      repository.SingleRepository
+	blockRepo repository.BlockRepository
+	auth      Authorizer
+	logger    log.Logger
+	c         context.Context
+	t         time.Duration
 }
 
-func NewSingleUsercase(repo repository.SingleRepository) SingleUsecase {
+func NewSingleUsercase(repo repository.SingleRepository, blockRepo repository.BlockRepository, auth Authorizer) SingleUsecase {
 	return &singleUsecase{
-		repo:   repo,
-		logger: repo.GetLogger(),
-		c:      context.Background(),
-		t:      5 * time.Second,
+		repo:      repo,
+		blockRepo: blockRepo,
+		auth:      auth,
+		logger:    repo.GetLogger(),
+		c:         context.Background(),
+		t:         5 * time.Second,
 	}
 }
 
@@ -61,11 +79,51 @@ func (u *singleUsecase) GetLogger() log.Logger {
 func (u *singleUsecase) InviteSingle(singleInvite *model.SingleInvite) (*model.SingleInviter, error) {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
+	// 被邀请者屏蔽了邀请者时不允许发起申请
+	blocked, err := u.blockRepo.FindBlocked(ctx, singleInvite.InviteeId, singleInvite.InviterId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrSingleInviteFail,
+			Message: constant.MsgSingleInviteFail,
+		}
+	}
+	if blocked {
+		return nil, &model.DError{
+			Code:    constant.ErrUserBlocked,
+			Message: constant.MsgUserBlocked,
+		}
+	}
 	// 逻辑未建立
 	singleInvite.Deleted = 1
+	singleInvite.Status = SINGLE_STATUS_PENDING
 	// 生成singleId
 	singleInvite.SingleId = u.generateSingleId(singleInvite.InviterId, singleInvite.InviteeId)
-	err := u.repo.InsertUnAccepted(ctx, singleInvite)
+	single := &model.Single{
+		SingleId:  singleInvite.SingleId,
+		InviterId: singleInvite.InviterId,
+		InviteeId: singleInvite.InviteeId,
+	}
+	exist, err := u.repo.FindStatus(ctx, single)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrSingleInviteFail,
+			Message: constant.MsgSingleInviteFail,
+		}
+	}
+	// 对方发起的申请同样有效，已拒绝、取消、过期或删除的单聊可以由任意一方重新申请
+	if exist && single.Deleted == 0 {
+		return nil, &model.DError{
+			Code:    constant.ErrSingleAlreadyFriend,
+			Message: constant.MsgSingleAlreadyFriend,
+		}
+	}
+	if exist && single.Status == SINGLE_STATUS_PENDING {
+		return nil, &model.DError{
+			Code:    constant.ErrSingleRequestPending,
+			Message: constant.MsgSingleRequestPending,
+		}
+	}
+	err = u.repo.InsertUnAccepted(ctx, singleInvite)
 	if err != nil {
 		return nil, &model.DError{
 			Code:    constant.ErrSingleInviteFail,
@@ -90,11 +148,13 @@ func (u *singleUsecase) AcceptSingle(singleAccept *model.SingleAccept) (*model.S
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
 	singleAccept.Deleted = 0
+	singleAccept.FromStatus = SINGLE_STATUS_PENDING
+	singleAccept.Status = SINGLE_STATUS_ACCEPTED
 	err := u.repo.UpdateByAccept(ctx, singleAccept)
 	if err != nil {
 		return nil, &model.DError{
-			Code:    constant.ErrSingleAcceptFail,
-			Message: constant.MsgSingleAcceptFail,
+			Code:    constant.ErrSingleRequestDone,
+			Message: constant.MsgSingleRequestDone,
 		}
 	}
 	singleInvitee := &model.SingleInvitee{
@@ -114,7 +174,11 @@ func (u *singleUsecase) AcceptSingle(singleAccept *model.SingleAccept) (*model.S
 func (u *singleUsecase) UpdateByInviter(singleInviter *model.SingleInviter) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
-	err := u.repo.UpdateByInviter(ctx, singleInviter)
+	err := u.auth.AuthorizeSingle(ctx, singleInviter.InviterId, singleInviter.SingleId)
+	if err != nil {
+		return err
+	}
+	err = u.repo.UpdateByInviter(ctx, singleInviter)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrSingleUpdateFail,
@@ -134,7 +198,11 @@ func (u *singleUsecase) UpdateByInviter(singleInviter *model.SingleInviter) erro
 func (u *singleUsecase) UpdateByInvitee(singleInvitee *model.SingleInvitee) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
-	err := u.repo.UpdateByInvitee(ctx, singleInvitee)
+	err := u.auth.AuthorizeSingle(ctx, singleInvitee.InviteeId, singleInvitee.SingleId)
+	if err != nil {
+		return err
+	}
+	err = u.repo.UpdateByInvitee(ctx, singleInvitee)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrSingleUpdateFail,
@@ -177,10 +245,14 @@ func (u *singleUsecase) GetDetailForInvitee(singleInvitee *model.SingleInvitee)
 	return nil
 }
 
-func (u *singleUsecase) Delete(singleDelete *model.SingleDelete) error {
+func (u *singleUsecase) Delete(operatorId int64, singleDelete *model.SingleDelete) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
-	err := u.repo.Delete(ctx, singleDelete)
+	err := u.auth.AuthorizeSingle(ctx, operatorId, singleDelete.SingleId)
+	if err != nil {
+		return err
+	}
+	err = u.repo.Delete(ctx, singleDelete)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrSingleDeleteFail,
@@ -189,3 +261,73 @@ func (u *singleUsecase) Delete(singleDelete *model.SingleDelete) error {
 	}
 	return nil
 }
+
+// tagId 不为 0 时按标签筛选
+func (u *singleUsecase) Contacts(operatorId, tagId int64, page *model.Page[*model.Contact]) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.repo.FindContacts(ctx, operatorId, SINGLE_STATUS_ACCEPTED, tagId, page)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 收到的(incoming)或发出的待处理申请
+func (u *singleUsecase) Requests(operatorId int64, incoming bool, page *model.Page[*model.SingleRequest]) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.repo.FindRequests(ctx, operatorId, incoming, SINGLE_STATUS_PENDING, page)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}
+
+// 受邀人拒绝申请
+func (u *singleUsecase) Decline(operatorId, singleId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.repo.UpdateStatus(ctx, singleId, operatorId, true, SINGLE_STATUS_PENDING, SINGLE_STATUS_DECLINED)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrSingleRequestDone,
+			Message: constant.MsgSingleRequestDone,
+		}
+	}
+	return nil
+}
+
+// 邀请人取消申请
+func (u *singleUsecase) Cancel(operatorId, singleId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.repo.UpdateStatus(ctx, singleId, operatorId, false, SINGLE_STATUS_PENDING, SINGLE_STATUS_CANCELLED)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrSingleRequestDone,
+			Message: constant.MsgSingleRequestDone,
+		}
+	}
+	return nil
+}
+
+// 将超过 ttl 仍未处理的申请标记为过期，由定时任务调用
+func (u *singleUsecase) ExpireRequests(ctx context.Context, ttl time.Duration) (int64, error) {
+	ctx, cancle := context.WithTimeout(ctx, u.t)
+	defer cancle()
+	n, err := u.repo.UpdateExpired(ctx, time.Now().Add(-ttl), SINGLE_STATUS_PENDING, SINGLE_STATUS_EXPIRED)
+	if err != nil {
+		return 0, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return n, nil
+}
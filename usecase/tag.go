@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/wendisx/gorchat/internal/constant"
+	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/model"
+	"github.com/wendisx/gorchat/repository"
+)
+
+type TagUsecase interface {
+	GetLogger() log.Logger
+	CreateTag(operatorId int64, tagName string) (*model.Tag, error)
+	UpdateTag(operatorId, tagId int64, tagName string) error
+	DeleteTag(operatorId, tagId int64) error
+	Tags(operatorId int64) ([]*model.Tag, error)
+	AddItem(operatorId int64, item *model.TagItem) error
+	RemoveItem(operatorId int64, item *model.TagItem) error
+}
+
+type tagUsecase struct {
+	repo      repository.TagRepository
+	groupRepo repository.GroupRepository
+	auth      Authorizer
+	logger    log.Logger
+	c         context.Context
+	t         time.Duration
+}
+
+func NewTagUsecase(repo repository.TagRepository, groupRepo repository.GroupRepository, auth Authorizer) TagUsecase {
+	return &tagUsecase{
+		repo:      repo,
+		groupRepo: groupRepo,
+		auth:      auth,
+		logger:    repo.GetLogger(),
+		c:         context.Background(),
+		t:         5 * time.Second,
+	}
+}
+
+func (u *tagUsecase) GetLogger() log.Logger {
+	return u.logger
+}
+
+// 标签只属于创建者，其他用户的标签视为不存在
+func (u *tagUsecase) findTag(ctx context.Context, operatorId, tagId int64) (*model.Tag, error) {
+	tag := &model.Tag{
+		TagId:  tagId,
+		UserId: operatorId,
+	}
+	err := u.repo.FindTag(ctx, tag)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrTagNotExist,
+			Message: constant.MsgTagNotExist,
+		}
+	}
+	return tag, nil
+}
+
+func (u *tagUsecase) CreateTag(operatorId int64, tagName string) (*model.Tag, error) {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	tag := &model.Tag{
+		UserId:  operatorId,
+		TagName: tagName,
+	}
+	ok, err := u.repo.InsertTag(ctx, tag)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrTagFail,
+			Message: constant.MsgTagFail,
+		}
+	}
+	if !ok {
+		return nil, &model.DError{
+			Code:    constant.ErrTagExist,
+			Message: constant.MsgTagExist,
+		}
+	}
+	return tag, nil
+}
+
+func (u *tagUsecase) UpdateTag(operatorId, tagId int64, tagName string) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	tag, err := u.findTag(ctx, operatorId, tagId)
+	if err != nil {
+		return err
+	}
+	tag.TagName = tagName
+	ok, err := u.repo.UpdateTag(ctx, tag)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTagFail,
+			Message: constant.MsgTagFail,
+		}
+	}
+	if !ok {
+		return &model.DError{
+			Code:    constant.ErrTagExist,
+			Message: constant.MsgTagExist,
+		}
+	}
+	return nil
+}
+
+func (u *tagUsecase) DeleteTag(operatorId, tagId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	_, err := u.findTag(ctx, operatorId, tagId)
+	if err != nil {
+		return err
+	}
+	err = u.repo.DeleteTag(ctx, tagId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTagFail,
+			Message: constant.MsgTagFail,
+		}
+	}
+	return nil
+}
+
+func (u *tagUsecase) Tags(operatorId int64) ([]*model.Tag, error) {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	tags, err := u.repo.FindTags(ctx, operatorId)
+	if err != nil {
+		return nil, &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return tags, nil
+}
+
+// 只能给自己参与的单聊或所在的群打标签
+func (u *tagUsecase) authorizeDialog(ctx context.Context, operatorId int64, item *model.TagItem) error {
+	switch item.DialogType {
+	case DIALOG_SINGLE:
+		return u.auth.AuthorizeSingle(ctx, operatorId, item.DialogId)
+	case DIALOG_GROUP:
+		groupToUser := &model.GroupToUser{
+			GroupId: item.DialogId,
+			UserId:  operatorId,
+		}
+		err := u.groupRepo.FindGroupToUser(ctx, groupToUser)
+		if err != nil {
+			return permissionDenied()
+		}
+		return nil
+	}
+	return &model.DError{
+		Code:    constant.ErrArgument,
+		Message: constant.MsgArgumentErr,
+	}
+}
+
+func (u *tagUsecase) AddItem(operatorId int64, item *model.TagItem) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	_, err := u.findTag(ctx, operatorId, item.TagId)
+	if err != nil {
+		return err
+	}
+	err = u.authorizeDialog(ctx, operatorId, item)
+	if err != nil {
+		return err
+	}
+	err = u.repo.InsertTagItem(ctx, item)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTagFail,
+			Message: constant.MsgTagFail,
+		}
+	}
+	return nil
+}
+
+// 取消关联不校验会话，已删除的单聊或已退出的群也可以从标签中移除
+func (u *tagUsecase) RemoveItem(operatorId int64, item *model.TagItem) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	_, err := u.findTag(ctx, operatorId, item.TagId)
+	if err != nil {
+		return err
+	}
+	err = u.repo.DeleteTagItem(ctx, item)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrTagFail,
+			Message: constant.MsgTagFail,
+		}
+	}
+	return nil
+}
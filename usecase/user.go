@@ -6,6 +6,7 @@ import (
 
 	"github.com/wendisx/gorchat/internal/constant"
 	"github.com/wendisx/gorchat/internal/log"
+	"github.com/wendisx/gorchat/internal/storage"
 	"github.com/wendisx/gorchat/model"
 	"github.com/wendisx/gorchat/repository"
 	"golang.org/x/crypto/bcrypt"
@@ -15,25 +16,36 @@ type UserUsecase interface {
 	GetLogger() log.Logger
 	Signup(userName string, userPassword string) (int64, error)
 	Login(userId int64, userPassword string) (*model.User, error)
-	UpdateInfo(user *model.User) (*model.User, error)
-	Delete(userId int64) error
+	UpdateInfo(operatorId int64, user *model.User) (*model.User, error)
+	Delete(operatorId, userId int64) error
 	GetUserDetail(userId int64) (*model.User, error)
-	SearchUsers(userId int64, userName string, page *model.Page[model.UserBasic]) error
+	SearchUsers(searcherId, userId int64, userName string, page *model.Page[model.UserBasic]) error
+	Block(operatorId, userId int64) error
+	Unblock(operatorId, userId int64) error
+	Blocks(operatorId int64, page *model.Page[*model.UserBlock]) error
 }
 
 type userUsecase struct {
-	repo   repository.UserRepository
-	logger log.Logger
-	c      context.Context
-	t      time.Duration
+	repo      repository.UserRepository
+	groupRepo repository.GroupRepository
+	blockRepo repository.BlockRepository
+	store     storage.BlobStore
+	auth      Authorizer
+	logger    log.Logger
+	c         context.Context
+	t         time.Duration
 }
 
-func NewUserUsecase(repo repository.UserRepository) UserUsecase {
+func NewUserUsecase(repo repository.UserRepository, groupRepo repository.GroupRepository, blockRepo repository.BlockRepository, store storage.BlobStore, auth Authorizer) UserUsecase {
 	return &userUsecase{
-		repo:   repo,
-		logger: repo.GetLogger(),
-		c:      context.Background(),
-		t:      5 * time.Second,
+		repo:      repo,
+		groupRepo: groupRepo,
+		blockRepo: blockRepo,
+		store:     store,
+		auth:      auth,
+		logger:    repo.GetLogger(),
+		c:         context.Background(),
+		t:         5 * time.Second,
 	}
 }
 
@@ -93,9 +105,13 @@ func (u *userUsecase) Login(userId int64, userPassword string) (*model.User, err
 	return user, nil
 }
 
-func (u *userUsecase) UpdateInfo(user *model.User) (*model.User, error) {
+func (u *userUsecase) UpdateInfo(operatorId int64, user *model.User) (*model.User, error) {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
+	err := u.auth.AuthorizeUser(operatorId, user.UserId)
+	if err != nil {
+		return user, err
+	}
 	tuser, err := u.repo.FindOneById(ctx, user.UserId)
 	if err != nil || tuser == nil {
 		return user, &model.DError{
@@ -103,6 +119,11 @@ func (u *userUsecase) UpdateInfo(user *model.User) (*model.User, error) {
 			Message: constant.MsgUserNotExist,
 		}
 	}
+	// 头像为附件存储中的 blob id
+	err = checkBlob(ctx, u.store, user.UserAvatar)
+	if err != nil {
+		return user, err
+	}
 	user, err = u.repo.UpdateOneById(ctx, user)
 	if err != nil || user == nil {
 		return user, &model.DError{
@@ -113,9 +134,13 @@ func (u *userUsecase) UpdateInfo(user *model.User) (*model.User, error) {
 	return user, nil
 }
 
-func (u *userUsecase) Delete(userId int64) error {
+func (u *userUsecase) Delete(operatorId, userId int64) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
+	err := u.auth.AuthorizeUser(operatorId, userId)
+	if err != nil {
+		return err
+	}
 	tuser, err := u.repo.FindOneById(ctx, userId)
 	if err != nil && tuser == nil {
 		return &model.DError{
@@ -123,6 +148,14 @@ func (u *userUsecase) Delete(userId int64) error {
 			Message: constant.MsgUserNotExist,
 		}
 	}
+	// 注销前移交名下的群
+	err = handOverGroups(ctx, u.groupRepo, userId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrUserDeleteFail,
+			Message: constant.MsgUserDeleteFail,
+		}
+	}
 	err = u.repo.DeleteOneById(ctx, userId)
 	if err != nil {
 		return &model.DError{
@@ -146,14 +179,14 @@ func (u *userUsecase) GetUserDetail(userId int64) (*model.User, error) {
 	return user, nil
 }
 
-func (u *userUsecase) SearchUsers(userId int64, userName string, page *model.Page[model.UserBasic]) error {
+func (u *userUsecase) SearchUsers(searcherId, userId int64, userName string, page *model.Page[model.UserBasic]) error {
 	ctx, cancle := context.WithTimeout(u.c, u.t)
 	defer cancle()
 	userBasic := model.UserBasic{
 		UserId:   userId,
 		UserName: userName,
 	}
-	err := u.repo.FindBasicLists(ctx, userBasic, page)
+	err := u.repo.FindBasicLists(ctx, searcherId, userBasic, page)
 	if err != nil {
 		return &model.DError{
 			Code:    constant.ErrSearchUser,
@@ -162,3 +195,56 @@ func (u *userUsecase) SearchUsers(userId int64, userName string, page *model.Pag
 	}
 	return nil
 }
+
+// 屏蔽用户，被屏蔽者不能再发起单聊申请或发送单聊消息
+func (u *userUsecase) Block(operatorId, userId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	if operatorId == userId {
+		return &model.DError{
+			Code:    constant.ErrArgument,
+			Message: constant.MsgArgumentErr,
+		}
+	}
+	_, err := u.repo.FindOneById(ctx, userId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrUserNotExist,
+			Message: constant.MsgUserNotExist,
+		}
+	}
+	err = u.blockRepo.InsertBlock(ctx, operatorId, userId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrUserBlockFail,
+			Message: constant.MsgUserBlockFail,
+		}
+	}
+	return nil
+}
+
+func (u *userUsecase) Unblock(operatorId, userId int64) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.blockRepo.DeleteBlock(ctx, operatorId, userId)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrUserBlockFail,
+			Message: constant.MsgUserBlockFail,
+		}
+	}
+	return nil
+}
+
+func (u *userUsecase) Blocks(operatorId int64, page *model.Page[*model.UserBlock]) error {
+	ctx, cancle := context.WithTimeout(u.c, u.t)
+	defer cancle()
+	err := u.blockRepo.FindBlocks(ctx, operatorId, page)
+	if err != nil {
+		return &model.DError{
+			Code:    constant.ErrOperationFail,
+			Message: constant.MsgOperationFail,
+		}
+	}
+	return nil
+}